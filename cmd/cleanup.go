@@ -12,21 +12,41 @@ import (
 	"github.com/spf13/cobra"
 )
 
-func removeHookFromFile(filePath string) error {
+// removeHookFromFile strips the cmdo hook from a shell config file after
+// backing it up, or with dryRun prints the change as a diff. It returns the
+// backup's path.
+func removeHookFromFile(filePath string, dryRun bool) (string, error) {
 	if !fileExists(filePath) {
-		return fmt.Errorf("file not found: %s", filePath)
+		return "", fmt.Errorf("file not found: %s", filePath)
 	}
 
 	content, err := os.ReadFile(filePath)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	originalContent := string(content)
+	cleanedContent, found, err := removeHook(filePath, originalContent)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", fmt.Errorf("no CMDO hook found")
+	}
+
+	if dryRun {
+		fmt.Print(unifiedDiff(filePath, originalContent, cleanedContent))
+		return "", nil
+	}
+	return writeHookFile(filePath, cleanedContent)
+}
 
-	// Check if hook exists
-	if !strings.Contains(originalContent, "CMDO Command Logger Hook") {
-		return fmt.Errorf("no CMDO hook found")
+// removeLegacyHook removes a hook installed before hooks were wrapped in
+// block markers, which has to be recognized by the shape of its code.
+func removeLegacyHook(filePath, originalContent string) (string, error) {
+	// The fish hook owns its whole conf.d file
+	if filepath.Base(filePath) == "cmdo.fish" {
+		return "", nil
 	}
 
 	var cleanedContent string
@@ -34,24 +54,20 @@ func removeHookFromFile(filePath string) error {
 	// Detect file type
 	if strings.HasSuffix(filePath, ".bashrc") || strings.HasSuffix(filePath, ".bash_profile") {
 		cleanedContent = removeBashHook(originalContent)
+	} else if strings.HasSuffix(filePath, ".zshrc") {
+		cleanedContent = removeZshHook(originalContent)
 	} else if strings.HasSuffix(filePath, ".ps1") {
 		cleanedContent = removePowerShellHook(originalContent)
 	} else {
-		return fmt.Errorf("unknown file type: %s", filePath)
+		return "", fmt.Errorf("unknown file type: %s", filePath)
 	}
 
 	// Check if anything was actually removed
 	if cleanedContent == originalContent {
-		return fmt.Errorf("no CMDO hook found or failed to remove")
+		return "", fmt.Errorf("no CMDO hook found or failed to remove")
 	}
 
-	// Write cleaned content back
-	err = os.WriteFile(filePath, []byte(cleanedContent), 0644)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return cleanedContent, nil
 }
 
 func removeBashHook(content string) string {
@@ -113,6 +129,50 @@ func removeBashHookLineByLine(content string) string {
 	return strings.Join(result, "\n")
 }
 
+func removeZshHook(content string) string {
+	// The zsh hook ends with the precmd registration line
+	pattern := `(?ms)# CMDO Command Logger Hook.*?^add-zsh-hook precmd __cmdo_precmd[ \t]*$`
+
+	re := regexp.MustCompile(pattern)
+	cleaned := re.ReplaceAllString(content, "")
+
+	// If regex didn't work, try line-by-line approach
+	if strings.Contains(cleaned, "CMDO Command Logger Hook") {
+		cleaned = removeZshHookLineByLine(content)
+	}
+
+	// Clean up excessive newlines
+	cleaned = cleanupExcessiveNewlines(cleaned)
+
+	return cleaned
+}
+
+func removeZshHookLineByLine(content string) string {
+	lines := strings.Split(content, "\n")
+	var result []string
+	inHook := false
+
+	for _, line := range lines {
+		// Start of hook
+		if strings.Contains(line, "# CMDO Command Logger Hook") {
+			inHook = true
+			continue
+		}
+
+		if inHook {
+			// Hook ends after the last add-zsh-hook registration
+			if strings.HasPrefix(strings.TrimSpace(line), "add-zsh-hook precmd") {
+				inHook = false
+			}
+			continue
+		}
+
+		result = append(result, line)
+	}
+
+	return strings.Join(result, "\n")
+}
+
 func removePowerShellHook(content string) string {
 	// Pattern to match PowerShell hook:
 	// From "# CMDO Command Logger Hook" to the closing brace of "function Global:prompt"
@@ -204,40 +264,81 @@ func max(a, b int) int {
 	return b
 }
 
+func getWindowsHookConfigFiles() map[string]string {
+	userProfile := os.Getenv("USERPROFILE")
+	configFiles := make(map[string]string)
+
+	// Bash config
+	bashrcPath := filepath.Join(userProfile, ".bashrc")
+	if fileExists(bashrcPath) {
+		configFiles["Git Bash"] = bashrcPath
+	}
+
+	// PowerShell Core profile
+	psCorePath := filepath.Join(userProfile, "Documents", "PowerShell", "Microsoft.PowerShell_profile.ps1")
+	if fileExists(psCorePath) {
+		configFiles["PowerShell Core"] = psCorePath
+	}
+
+	// Windows PowerShell profile
+	winPSPath := filepath.Join(userProfile, "Documents", "WindowsPowerShell", "Microsoft.PowerShell_profile.ps1")
+	if fileExists(winPSPath) {
+		configFiles["Windows PowerShell"] = winPSPath
+	}
+
+	return configFiles
+}
+
+func getUnixHookConfigFiles() map[string]string {
+	homeDir, _ := os.UserHomeDir()
+	configFiles := make(map[string]string)
+
+	// setup writes to whichever of these bash reads on this OS, so check both
+	bashrcPath := filepath.Join(homeDir, ".bashrc")
+	if fileExists(bashrcPath) {
+		configFiles["Bash (.bashrc)"] = bashrcPath
+	}
+
+	bashProfilePath := filepath.Join(homeDir, ".bash_profile")
+	if fileExists(bashProfilePath) {
+		configFiles["Bash (.bash_profile)"] = bashProfilePath
+	}
+
+	zshrcPath := getZshConfigPath()
+	if fileExists(zshrcPath) {
+		configFiles["Zsh"] = zshrcPath
+	}
+
+	// Also check the default location in case $ZDOTDIR changed since setup
+	homeZshrcPath := filepath.Join(homeDir, ".zshrc")
+	if homeZshrcPath != zshrcPath && fileExists(homeZshrcPath) {
+		configFiles["Zsh (~/.zshrc)"] = homeZshrcPath
+	}
+
+	fishHookPath := getFishHookPath()
+	if fileExists(fishHookPath) {
+		configFiles["Fish"] = fishHookPath
+	}
+
+	return configFiles
+}
+
 var cleanupCmd = &cobra.Command{
 	Use:     "cleanup",
 	Aliases: []string{"uninstall", "remove"},
 	Short:   "Remove CMDO hooks from shell config files",
-	Long:    "Removes all CMDO Command Logger hooks from detected shell configuration files",
+	Long:    "Removes all CMDO Command Logger hooks from detected shell configuration files, keeping a timestamped backup of each file it changes. Use --dry-run to see the changes as a diff first.",
 	Run: func(cmd *cobra.Command, args []string) {
 		currentOS := runtime.GOOS
-
-		if currentOS != "windows" {
-			fmt.Println("❌ Currently only Windows is supported")
-			return
-		}
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
 
 		fmt.Println("🧹 Cleaning up CMDO hooks...")
 
-		userProfile := os.Getenv("USERPROFILE")
-		configFiles := make(map[string]string)
-
-		// Bash config
-		bashrcPath := filepath.Join(userProfile, ".bashrc")
-		if fileExists(bashrcPath) {
-			configFiles["Git Bash"] = bashrcPath
-		}
-
-		// PowerShell Core profile
-		psCorePath := filepath.Join(userProfile, "Documents", "PowerShell", "Microsoft.PowerShell_profile.ps1")
-		if fileExists(psCorePath) {
-			configFiles["PowerShell Core"] = psCorePath
-		}
-
-		// Windows PowerShell profile
-		winPSPath := filepath.Join(userProfile, "Documents", "WindowsPowerShell", "Microsoft.PowerShell_profile.ps1")
-		if fileExists(winPSPath) {
-			configFiles["Windows PowerShell"] = winPSPath
+		var configFiles map[string]string
+		if currentOS == "windows" {
+			configFiles = getWindowsHookConfigFiles()
+		} else {
+			configFiles = getUnixHookConfigFiles()
 		}
 
 		if len(configFiles) == 0 {
@@ -252,7 +353,7 @@ var cleanupCmd = &cobra.Command{
 		for shellName, configPath := range configFiles {
 			fmt.Printf("🔍 Checking %s (%s)...\n", shellName, configPath)
 
-			err := removeHookFromFile(configPath)
+			backup, err := removeHookFromFile(configPath, dryRun)
 			if err != nil {
 				if strings.Contains(err.Error(), "no CMDO hook found") {
 					fmt.Printf("   ℹ️  No hook found\n")
@@ -261,12 +362,22 @@ var cleanupCmd = &cobra.Command{
 					fmt.Printf("   ❌ Error: %v\n", err)
 					failed++
 				}
+			} else if dryRun {
+				removed++
 			} else {
 				fmt.Printf("   ✅ Hook removed successfully\n")
+				if backup != "" {
+					fmt.Printf("   💾 Backup: %s\n", backup)
+				}
 				removed++
 			}
 		}
 
+		if dryRun {
+			fmt.Printf("\n🔎 Dry run: %d hook(s) would be removed, nothing was changed\n", removed)
+			return
+		}
+
 		fmt.Printf("\n📊 Summary:\n")
 		fmt.Printf("   ✅ Removed: %d\n", removed)
 		fmt.Printf("   ℹ️  Not found: %d\n", notFound)
@@ -278,7 +389,17 @@ var cleanupCmd = &cobra.Command{
 			fmt.Println("\n📝 Next steps:")
 			fmt.Println("   1. Restart your terminal, OR")
 			fmt.Println("   2. For Bash: source ~/.bashrc")
-			fmt.Println("   3. For PowerShell: . $PROFILE")
+			if currentOS == "windows" {
+				fmt.Println("   3. For PowerShell: . $PROFILE")
+			} else {
+				fmt.Println("   3. For Zsh: exec zsh")
+				fmt.Println("   4. For Fish: exec fish")
+			}
+		}
+
+		if currentOS != "windows" {
+			fmt.Println("\n✨ Cleanup complete!")
+			return
 		}
 
 		// Check for WSL
@@ -304,5 +425,6 @@ var cleanupCmd = &cobra.Command{
 }
 
 func init() {
+	cleanupCmd.Flags().Bool("dry-run", false, "Show the changes as a diff without modifying any file")
 	rootCmd.AddCommand(cleanupCmd)
 }
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/tanu2534/cmdo/config"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Read and change cmdo settings",
+	Long: `config manages the settings in ~/.cmdo/config.toml ($CMDO_CONFIG to use another file).
+Every key can be overridden by an environment variable, e.g. server.port by CMDO_SERVER_PORT, and flags such as --db or --port override both.`,
+}
+
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print the config file location",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println(config.Path())
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List every setting and its effective value",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.Get()
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, key := range config.Keys() {
+			value, _ := cfg.Get(key)
+			source := ""
+			if _, ok := os.LookupEnv(config.EnvName(key)); ok {
+				source = "(from " + config.EnvName(key) + ")"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", key, value, source)
+		}
+		w.Flush()
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the effective value of a setting",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		value, err := config.Get().Get(args[0])
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+		fmt.Println(value)
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Change a setting in the config file",
+	Long: `set writes a setting to the config file. List settings take a TOML array:
+  cmdo config set ignore.patterns '["ls", "cd *", "dir:~/secret/**"]'
+A single value sets a one-element list and "" clears it.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		key, value := args[0], args[1]
+
+		err := config.Update(func(cfg *config.Config) error {
+			return cfg.Set(key, value)
+		})
+		if err != nil {
+			fmt.Println("❌ Error updating config:", err)
+			os.Exit(1)
+		}
+
+		saved, _ := config.Get().Get(key)
+		fmt.Printf("✅ %s = %s\n", key, saved)
+		if _, ok := os.LookupEnv(config.EnvName(key)); ok {
+			fmt.Printf("⚠️  %s is set and overrides the config file\n", config.EnvName(key))
+		}
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configPathCmd)
+	configCmd.AddCommand(configListCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,310 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tanu2534/cmdo/database"
+)
+
+const (
+	// daemonDialTimeout bounds how long 'cmdo log' waits for the daemon
+	// before writing to the database itself.
+	daemonDialTimeout = 100 * time.Millisecond
+	// daemonBatchSize and daemonBatchDelay bound how many commands the
+	// daemon collects, and for how long, before writing them in one
+	// transaction.
+	daemonBatchSize  = 100
+	daemonBatchDelay = 200 * time.Millisecond
+)
+
+var errDaemonRunning = errors.New("a cmdo daemon is already running for this database")
+
+// daemonRequest is one newline-delimited JSON message to the daemon. Log
+// requests get no reply; ping and stop are answered with a daemonStatus.
+type daemonRequest struct {
+	Type  string             `json:"type"`
+	Entry *database.LogEntry `json:"entry,omitempty"`
+}
+
+type daemonStatus struct {
+	PID       int       `json:"pid"`
+	DBPath    string    `json:"dbPath"`
+	StartedAt time.Time `json:"startedAt"`
+	Logged    int64     `json:"logged"`
+}
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Run the background logger",
+	Long: `daemon keeps the database open and accepts commands from 'cmdo log' over a Unix socket (a named pipe on Windows),
+writing them in batched transactions so the shell prompt never waits on the disk. 'cmdo log' falls back to writing
+the database itself whenever no daemon is running.
+
+Without a subcommand the daemon runs in the foreground, e.g. under systemd or launchd; use 'cmdo daemon start' to
+run it in the background.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runDaemon(); err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var daemonStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start the daemon in the background",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if status, err := daemonRoundTrip("ping"); err == nil {
+			fmt.Printf("✅ Daemon already running (pid %d)\n", status.PID)
+			return
+		}
+
+		exe, err := os.Executable()
+		if err != nil {
+			fmt.Println("❌ Error finding cmdo binary:", err)
+			os.Exit(1)
+		}
+
+		dbPath := database.GetGlobalDBPath()
+		logPath := filepath.Join(filepath.Dir(dbPath), "daemon.log")
+		if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+			fmt.Println("❌ Error creating log directory:", err)
+			os.Exit(1)
+		}
+		logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			fmt.Println("❌ Error opening daemon log:", err)
+			os.Exit(1)
+		}
+		defer logFile.Close()
+
+		child := exec.Command(exe, "daemon", "--db", dbPath)
+		child.Stdout = logFile
+		child.Stderr = logFile
+		detachDaemon(child)
+		if err := child.Start(); err != nil {
+			fmt.Println("❌ Error starting daemon:", err)
+			os.Exit(1)
+		}
+		child.Process.Release()
+
+		// Wait until it answers, so the next 'cmdo log' already uses it
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+			if status, err := daemonRoundTrip("ping"); err == nil {
+				fmt.Printf("🚀 Daemon started (pid %d), logging to %s\n", status.PID, logPath)
+				return
+			}
+		}
+		fmt.Println("❌ Daemon did not come up, see", logPath)
+		os.Exit(1)
+	},
+}
+
+var daemonStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the background daemon",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		status, err := daemonRoundTrip("stop")
+		if err != nil {
+			fmt.Println("Daemon is not running")
+			return
+		}
+		fmt.Printf("🛑 Stopped daemon (pid %d) after logging %d command(s)\n", status.PID, status.Logged)
+	},
+}
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the daemon is running",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		status, err := daemonRoundTrip("ping")
+		if err != nil {
+			fmt.Println("⚪ Daemon is not running; 'cmdo log' writes to the database directly")
+			os.Exit(1)
+		}
+		fmt.Printf("🟢 Daemon running (pid %d) since %s\n", status.PID, status.StartedAt.Format(database.TimestampFormat))
+		fmt.Printf("   Address:  %s\n", daemonAddress())
+		fmt.Printf("   Database: %s\n", status.DBPath)
+		fmt.Printf("   Logged:   %d command(s)\n", status.Logged)
+	},
+}
+
+// runDaemon serves log requests until it is stopped by a stop request or a
+// signal, then writes whatever is still queued and closes the database.
+func runDaemon() error {
+	dbPath := database.GetGlobalDBPath()
+	if err := database.OpenDB(dbPath); err != nil {
+		return err
+	}
+	defer database.DB.Close()
+	if _, err := database.Migrate(); err != nil {
+		return fmt.Errorf("error migrating database: %w", err)
+	}
+
+	listener, err := daemonListen()
+	if err != nil {
+		return err
+	}
+
+	status := daemonStatus{PID: os.Getpid(), DBPath: dbPath, StartedAt: time.Now()}
+	var logged atomic.Int64
+
+	queue := make(chan database.LogEntry, 1024)
+	written := make(chan struct{})
+	go func() {
+		writeBatches(queue, &logged)
+		close(written)
+	}()
+
+	var stopOnce sync.Once
+	stop := func() { stopOnce.Do(func() { listener.Close() }) }
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		stop()
+	}()
+
+	log.Printf("cmdo daemon (pid %d) listening on %s for %s", status.PID, daemonAddress(), dbPath)
+
+	var conns sync.WaitGroup
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			break
+		}
+		conns.Add(1)
+		go func() {
+			defer conns.Done()
+			handleDaemonConn(conn, queue, func() daemonStatus {
+				s := status
+				s.Logged = logged.Load()
+				return s
+			}, stop)
+		}()
+	}
+
+	conns.Wait()
+	close(queue)
+	<-written
+	log.Printf("cmdo daemon stopped after logging %d command(s)", logged.Load())
+	return nil
+}
+
+func handleDaemonConn(conn net.Conn, queue chan<- database.LogEntry, status func() daemonStatus, stop func()) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var req daemonRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			log.Printf("Ignoring malformed request: %s", err)
+			return
+		}
+
+		switch req.Type {
+		case "log":
+			if req.Entry != nil {
+				queue <- *req.Entry
+			}
+		case "ping":
+			json.NewEncoder(conn).Encode(status())
+		case "stop":
+			json.NewEncoder(conn).Encode(status())
+			stop()
+			return
+		default:
+			log.Printf("Ignoring unknown request type %q", req.Type)
+		}
+	}
+}
+
+// writeBatches inserts queued commands, gathering whatever arrives within
+// daemonBatchDelay of the first into the same transaction.
+func writeBatches(queue <-chan database.LogEntry, logged *atomic.Int64) {
+	for entry := range queue {
+		batch := []database.LogEntry{entry}
+		timeout := time.After(daemonBatchDelay)
+
+	collect:
+		for len(batch) < daemonBatchSize {
+			select {
+			case next, ok := <-queue:
+				if !ok {
+					break collect
+				}
+				batch = append(batch, next)
+			case <-timeout:
+				break collect
+			}
+		}
+
+		if err := database.InsertCmds(batch); err != nil {
+			log.Printf("Error inserting %d command(s): %s", len(batch), err)
+			continue
+		}
+		logged.Add(int64(len(batch)))
+
+		if err := applyRetention(); err != nil {
+			log.Printf("Error applying retention: %s", err)
+		}
+	}
+}
+
+// sendToDaemon hands a command to the daemon without waiting for it to be
+// written. It fails fast when no daemon is running.
+func sendToDaemon(entry database.LogEntry) error {
+	conn, err := daemonDial(daemonDialTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	conn.SetWriteDeadline(time.Now().Add(time.Second))
+	return json.NewEncoder(conn).Encode(daemonRequest{Type: "log", Entry: &entry})
+}
+
+func daemonRoundTrip(requestType string) (daemonStatus, error) {
+	var status daemonStatus
+
+	conn, err := daemonDial(time.Second)
+	if err != nil {
+		return status, err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if err := json.NewEncoder(conn).Encode(daemonRequest{Type: requestType}); err != nil {
+		return status, err
+	}
+	err = json.NewDecoder(conn).Decode(&status)
+	return status, err
+}
+
+func init() {
+	daemonCmd.AddCommand(daemonStartCmd)
+	daemonCmd.AddCommand(daemonStopCmd)
+	daemonCmd.AddCommand(daemonStatusCmd)
+	rootCmd.AddCommand(daemonCmd)
+}
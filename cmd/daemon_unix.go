@@ -0,0 +1,62 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/tanu2534/cmdo/database"
+)
+
+// daemonAddress is the Unix socket of the daemon serving the current
+// database, so daemons for different databases don't meet.
+func daemonAddress() string {
+	return database.GetGlobalDBPath() + ".sock"
+}
+
+func daemonListen() (net.Listener, error) {
+	address := daemonAddress()
+
+	listener, err := listenPrivate(address)
+	if err != nil && errors.Is(err, syscall.EADDRINUSE) {
+		// A socket nobody answers on was left behind by a crashed daemon
+		if conn, dialErr := daemonDial(time.Second); dialErr == nil {
+			conn.Close()
+			return nil, errDaemonRunning
+		}
+		os.Remove(address)
+		listener, err = listenPrivate(address)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chmod(address, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// listenPrivate creates the socket with only the owner allowed to connect.
+// Only the owner may log into their history, and a chmod after the socket
+// exists would leave a moment in which anyone could.
+func listenPrivate(address string) (net.Listener, error) {
+	umask := syscall.Umask(0077)
+	defer syscall.Umask(umask)
+	return net.Listen("unix", address)
+}
+
+func daemonDial(timeout time.Duration) (net.Conn, error) {
+	return net.DialTimeout("unix", daemonAddress(), timeout)
+}
+
+// detachDaemon makes the daemon process outlive the terminal that started it.
+func detachDaemon(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
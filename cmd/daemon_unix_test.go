@@ -0,0 +1,60 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/tanu2534/cmdo/database"
+)
+
+func TestDaemonListenPrivate(t *testing.T) {
+	if err := database.OpenDB(filepath.Join(t.TempDir(), "cmdo.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.DB.Close() })
+
+	umask := syscall.Umask(0022)
+	defer syscall.Umask(umask)
+
+	listener, err := daemonListen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	info, err := os.Stat(daemonAddress())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("socket mode = %o, want 600", perm)
+	}
+	if got := syscall.Umask(0022); got != 0022 {
+		t.Errorf("umask left at %o, want it restored to 022", got)
+	}
+}
+
+func TestListenPrivate(t *testing.T) {
+	umask := syscall.Umask(0)
+	defer syscall.Umask(umask)
+
+	address := filepath.Join(t.TempDir(), "test.sock")
+	listener, err := listenPrivate(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	// Checked before any chmod: the socket has to be private from the start
+	info, err := os.Stat(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm&0077 != 0 {
+		t.Errorf("socket mode = %o, want no access for group and others", perm)
+	}
+}
@@ -0,0 +1,47 @@
+//go:build windows
+
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Microsoft/go-winio"
+	"github.com/tanu2534/cmdo/database"
+)
+
+// daemonAddress is the named pipe of the daemon serving the current
+// database, so daemons for different databases don't meet.
+func daemonAddress() string {
+	sum := sha256.Sum256([]byte(strings.ToLower(database.GetGlobalDBPath())))
+	return `\\.\pipe\cmdo-` + hex.EncodeToString(sum[:8])
+}
+
+func daemonListen() (net.Listener, error) {
+	// The default security descriptor only admits the current user, SYSTEM
+	// and administrators
+	listener, err := winio.ListenPipe(daemonAddress(), nil)
+	if err != nil && errors.Is(err, syscall.ERROR_ACCESS_DENIED) {
+		return nil, errDaemonRunning
+	}
+	return listener, err
+}
+
+func daemonDial(timeout time.Duration) (net.Conn, error) {
+	return winio.DialPipe(daemonAddress(), &timeout)
+}
+
+// detachDaemon makes the daemon process outlive the console that started it.
+func detachDaemon(cmd *exec.Cmd) {
+	const detachedProcess = 0x00000008
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: detachedProcess | syscall.CREATE_NEW_PROCESS_GROUP,
+		HideWindow:    true,
+	}
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tanu2534/cmdo/database"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Manage the CMDO database",
+	Long:  "db groups commands that inspect and maintain the command history database",
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade the database schema",
+	Long:  "migrate applies any pending schema migrations to the database. Use --status to list migrations without applying them.",
+	Run: func(cmd *cobra.Command, args []string) {
+		showStatus, _ := cmd.Flags().GetBool("status")
+		dbPath := database.GetGlobalDBPath()
+
+		if err := database.OpenDB(dbPath); err != nil {
+			fmt.Println("❌ Error opening database:", err)
+			os.Exit(1)
+		}
+		defer database.DB.Close()
+
+		fmt.Printf("Database: %s\n", dbPath)
+
+		if showStatus {
+			current, err := database.SchemaVersion()
+			if err != nil {
+				fmt.Println("❌ Error reading schema version:", err)
+				os.Exit(1)
+			}
+
+			statuses, err := database.GetMigrationStatus()
+			if err != nil {
+				fmt.Println("❌ Error reading migrations:", err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("Schema version: %d (latest: %d)\n\n", current, database.LatestSchemaVersion())
+			pending := 0
+			for _, s := range statuses {
+				if s.Applied {
+					fmt.Printf("   ✅ %3d  %-55s applied %s\n", s.Version, s.Description, s.AppliedAt)
+				} else {
+					fmt.Printf("   ⏳ %3d  %-55s pending\n", s.Version, s.Description)
+					pending++
+				}
+			}
+
+			if pending > 0 {
+				fmt.Printf("\n%d pending migration(s). Run 'cmdo db migrate' to apply them.\n", pending)
+			} else {
+				fmt.Println("\n✨ Database is up to date")
+			}
+			return
+		}
+
+		applied, err := database.Migrate()
+		if err != nil {
+			fmt.Println("❌ Migration failed:", err)
+			os.Exit(1)
+		}
+
+		if len(applied) == 0 {
+			fmt.Println("✨ Database is already up to date")
+			return
+		}
+
+		for _, s := range applied {
+			fmt.Printf("   ✅ %3d  %s\n", s.Version, s.Description)
+		}
+		fmt.Printf("\n✨ Applied %d migration(s), schema version is now %d\n", len(applied), database.LatestSchemaVersion())
+	},
+}
+
+func init() {
+	dbMigrateCmd.Flags().Bool("status", false, "Show applied and pending migrations without applying them")
+	dbCmd.AddCommand(dbMigrateCmd)
+	rootCmd.AddCommand(dbCmd)
+}
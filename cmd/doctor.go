@@ -0,0 +1,306 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tanu2534/cmdo/config"
+	"github.com/tanu2534/cmdo/database"
+)
+
+// doctorCheck is one line of the `cmdo doctor` report.
+type doctorCheck struct {
+	Name string `json:"name"`
+	// Status is pass, warn, fail or skip
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+}
+
+type doctorReport struct {
+	OK     bool          `json:"ok"`
+	Checks []doctorCheck `json:"checks"`
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check that cmdo is set up and logging",
+	Long: `doctor checks the shell hooks (installed, current version, binary they call still exists), the config file,
+the database (writable, PRAGMA integrity_check, schema version) and when the last command was logged.
+It exits with 1 if any check fails. Use --json for machine-readable output.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		var checks []doctorCheck
+		checks = append(checks, checkConfig())
+		checks = append(checks, checkHooks()...)
+		checks = append(checks, checkDatabase()...)
+		checks = append(checks, checkDaemon())
+
+		report := doctorReport{OK: true, Checks: checks}
+		for _, c := range checks {
+			if c.Status == "fail" {
+				report.OK = false
+			}
+		}
+
+		if asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			enc.Encode(report)
+		} else {
+			printDoctorReport(report)
+		}
+
+		if !report.OK {
+			os.Exit(1)
+		}
+	},
+}
+
+func printDoctorReport(report doctorReport) {
+	icons := map[string]string{"pass": "✅", "warn": "⚠️ ", "fail": "❌", "skip": "⚪"}
+
+	fmt.Println("🩺 cmdo doctor")
+	fmt.Println()
+	for _, c := range report.Checks {
+		fmt.Printf("%s %s: %s\n", icons[c.Status], c.Name, c.Detail)
+	}
+
+	fmt.Println()
+	if report.OK {
+		fmt.Println("✨ No problems found")
+	} else {
+		fmt.Println("Some checks failed, see above")
+	}
+}
+
+func checkConfig() doctorCheck {
+	check := doctorCheck{Name: "Config"}
+	if _, err := config.Load(); err != nil {
+		check.Status = "fail"
+		check.Detail = err.Error()
+		return check
+	}
+
+	check.Status = "pass"
+	check.Detail = config.Path()
+	if !fileExists(config.Path()) {
+		check.Detail += " (not created, using defaults)"
+	}
+	return check
+}
+
+// checkHooks reports on every shell config file cmdo knows about.
+func checkHooks() []doctorCheck {
+	var configFiles map[string]string
+	if runtime.GOOS == "windows" {
+		configFiles = getWindowsHookConfigFiles()
+	} else {
+		configFiles = getUnixHookConfigFiles()
+	}
+
+	names := make([]string, 0, len(configFiles))
+	for name := range configFiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var checks []doctorCheck
+	installed := 0
+	for _, name := range names {
+		check := checkHookFile("Hook "+name, configFiles[name])
+		if check.Status != "skip" {
+			installed++
+		}
+		checks = append(checks, check)
+	}
+
+	if installed == 0 {
+		checks = append(checks, doctorCheck{
+			Name:   "Hooks",
+			Status: "fail",
+			Detail: "no shell has the cmdo hook installed, run 'cmdo setup'",
+		})
+	}
+	return checks
+}
+
+func checkHookFile(name, path string) doctorCheck {
+	check := doctorCheck{Name: name}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		check.Status = "fail"
+		check.Detail = err.Error()
+		return check
+	}
+
+	hook, marked := installedHookBlock(string(content))
+	if !marked {
+		if !strings.Contains(string(content), legacyHookMarker) {
+			check.Status = "skip"
+			check.Detail = "not installed in " + path
+			return check
+		}
+		hook = string(content)
+	}
+
+	binary := hookBinaryPath(hook)
+	version := hookBlockVersion(hook)
+	switch {
+	case binary == "":
+		check.Status = "fail"
+		check.Detail = fmt.Sprintf("hook in %s doesn't call cmdo, run 'cmdo setup' to reinstall it", path)
+	case !fileExists(binary):
+		check.Status = "fail"
+		check.Detail = fmt.Sprintf("hook in %s calls %s, which no longer exists; run 'cmdo setup'", path, binary)
+	case !marked:
+		check.Status = "warn"
+		check.Detail = fmt.Sprintf("legacy hook without cmdo markers in %s, run 'cmdo setup' to upgrade", path)
+	case version < hookVersion:
+		check.Status = "warn"
+		check.Detail = fmt.Sprintf("outdated hook (v%d, current v%d) in %s, run 'cmdo setup' to upgrade", version, hookVersion, path)
+	default:
+		check.Status = "pass"
+		check.Detail = fmt.Sprintf("v%d in %s, calls %s", version, path, binary)
+	}
+	return check
+}
+
+func checkDatabase() []doctorCheck {
+	dbPath := database.GetGlobalDBPath()
+	access := doctorCheck{Name: "Database", Status: "pass", Detail: dbPath + " is writable"}
+
+	if err := checkWritable(dbPath); err != nil {
+		access.Status = "fail"
+		access.Detail = err.Error()
+		return []doctorCheck{access}
+	}
+	if !fileExists(dbPath) {
+		access.Status = "warn"
+		access.Detail = dbPath + " doesn't exist yet; it is created when the first command is logged"
+		return []doctorCheck{access}
+	}
+
+	if err := database.OpenDB(dbPath); err != nil {
+		access.Status = "fail"
+		access.Detail = err.Error()
+		return []doctorCheck{access}
+	}
+	defer database.DB.Close()
+
+	checks := []doctorCheck{access}
+
+	integrity := doctorCheck{Name: "Integrity", Status: "pass", Detail: "PRAGMA integrity_check: ok"}
+	var result string
+	if err := database.DB.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		integrity.Status = "fail"
+		integrity.Detail = err.Error()
+	} else if result != "ok" {
+		integrity.Status = "fail"
+		integrity.Detail = "PRAGMA integrity_check: " + result
+	}
+	checks = append(checks, integrity)
+
+	schema := doctorCheck{Name: "Schema"}
+	version, err := database.SchemaVersion()
+	latest := database.LatestSchemaVersion()
+	switch {
+	case err != nil:
+		schema.Status = "fail"
+		schema.Detail = err.Error()
+	case version > latest:
+		schema.Status = "fail"
+		schema.Detail = fmt.Sprintf("version %d is newer than this cmdo supports (%d), upgrade cmdo", version, latest)
+	case version < latest:
+		schema.Status = "warn"
+		schema.Detail = fmt.Sprintf("version %d, %d pending migration(s) are applied on next use or by 'cmdo db migrate'", version, latest-version)
+	default:
+		schema.Status = "pass"
+		schema.Detail = fmt.Sprintf("version %d (latest)", version)
+	}
+	checks = append(checks, schema)
+
+	return append(checks, checkLastCommand())
+}
+
+// checkWritable makes sure the database file, or the directory it will be
+// created in, can be written.
+func checkWritable(dbPath string) error {
+	if fileExists(dbPath) {
+		f, err := os.OpenFile(dbPath, os.O_WRONLY, 0)
+		if err != nil {
+			return fmt.Errorf("%s is not writable: %w", dbPath, err)
+		}
+		return f.Close()
+	}
+
+	dir := filepath.Dir(dbPath)
+	for !fileExists(dir) && filepath.Dir(dir) != dir {
+		dir = filepath.Dir(dir)
+	}
+	f, err := os.CreateTemp(dir, ".cmdo-doctor-*")
+	if err != nil {
+		return fmt.Errorf("cannot create files in %s: %w", dir, err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+func checkLastCommand() doctorCheck {
+	check := doctorCheck{Name: "Last command"}
+
+	var last *string
+	err := database.DB.QueryRow("SELECT MAX(timestamp) FROM commands WHERE source IS NULL").Scan(&last)
+	if err != nil {
+		// Databases before the source column
+		err = database.DB.QueryRow("SELECT MAX(timestamp) FROM commands").Scan(&last)
+	}
+	switch {
+	case err != nil:
+		check.Status = "fail"
+		check.Detail = err.Error()
+		return check
+	case last == nil:
+		check.Status = "warn"
+		check.Detail = "no command has been logged by a shell hook yet"
+		return check
+	}
+
+	check.Status = "pass"
+	check.Detail = *last
+	if t, err := time.ParseInLocation(database.TimestampFormat, *last, time.Local); err == nil {
+		age := time.Since(t)
+		if ago := formatAge(age); ago == "now" {
+			check.Detail += " (just now)"
+		} else {
+			check.Detail += " (" + ago + " ago)"
+		}
+		if age > 24*time.Hour {
+			check.Status = "warn"
+			check.Detail += "; if you have used a shell since, logging is not working"
+		}
+	}
+	return check
+}
+
+func checkDaemon() doctorCheck {
+	status, err := daemonRoundTrip("ping")
+	if err != nil {
+		return doctorCheck{Name: "Daemon", Status: "skip", Detail: "not running, 'cmdo log' writes to the database directly"}
+	}
+	return doctorCheck{Name: "Daemon", Status: "pass", Detail: fmt.Sprintf("running (pid %d) on %s", status.PID, daemonAddress())}
+}
+
+func init() {
+	doctorCmd.Flags().Bool("json", false, "Print the report as JSON")
+	rootCmd.AddCommand(doctorCmd)
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		file       string
+		wantStatus string
+		wantDetail string
+	}{
+		{"no file", "", "pass", "not created"},
+		{"valid file", "[server]\nport = 9000\n", "pass", "config.toml"},
+		{"unknown key", "bogus = 1\n", "fail", "bogus"},
+		{"broken file", "[server\n", "fail", "error reading"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if tt.file != "" {
+				if err := os.WriteFile(path, []byte(tt.file), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Setenv("CMDO_CONFIG", path)
+
+			check := checkConfig()
+			if check.Status != tt.wantStatus || !strings.Contains(check.Detail, tt.wantDetail) {
+				t.Errorf("checkConfig() = %+v, want status %s mentioning %q", check, tt.wantStatus, tt.wantDetail)
+			}
+		})
+	}
+}
+
+// doctor has to get past the config loading in the root command to report
+// a broken file.
+func TestDoctorRunsWithBrokenConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("bogus = 1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CMDO_CONFIG", path)
+
+	// Exiting here would end the test binary
+	rootCmd.PersistentPreRun(doctorCmd, nil)
+}
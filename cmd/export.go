@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tanu2534/cmdo/database"
+	"github.com/tanu2534/cmdo/histfile"
+)
+
+var exportFormats = append([]string{"json", "jsonl", "csv"}, histfile.Formats...)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [query]",
+	Short: "Export command history",
+	Long: `export writes command history, oldest first, in one of these formats:
+
+  json, jsonl     every field, as served by /api/commands
+  csv             every field, one row per command
+  bash_history    bash with HISTTIMEFORMAT: "#<epoch>" before each command
+  zsh_extended    zsh EXTENDED_HISTORY: ": <start>:<seconds>;<command>"
+  fish            fish_history records with cmd and when
+
+Shell formats keep the start time (and zsh the duration) but have no place for exit codes or directories.
+The optional query and the filter flags work as in 'cmdo search'.`,
+	Args: cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		format, _ := cmd.Flags().GetString("format")
+		output, _ := cmd.Flags().GetString("output")
+
+		if !slices.Contains(exportFormats, format) {
+			fmt.Printf("❌ Unknown format %q, use one of: %s\n", format, strings.Join(exportFormats, ", "))
+			os.Exit(1)
+		}
+
+		opts, err := searchOptionsFromFlags(cmd)
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+		opts.Query = strings.Join(args, " ")
+
+		if err := database.OpenDB(database.GetGlobalDBPath()); err != nil {
+			fmt.Println("❌ Error opening database:", err)
+			os.Exit(1)
+		}
+		defer database.DB.Close()
+		if _, err := database.Migrate(); err != nil {
+			fmt.Println("❌ Error migrating database:", err)
+			os.Exit(1)
+		}
+
+		out := io.Writer(os.Stdout)
+		if output != "" && output != "-" {
+			file, err := os.Create(output)
+			if err != nil {
+				fmt.Println("❌ Error creating output file:", err)
+				os.Exit(1)
+			}
+			defer file.Close()
+			out = file
+		}
+
+		count, err := exportCommands(out, format, opts)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "❌ Error exporting commands:", err)
+			os.Exit(1)
+		}
+
+		if out != os.Stdout {
+			fmt.Printf("✅ Exported %d command(s) to %s\n", count, output)
+		}
+	},
+}
+
+// exportCommands writes the commands matching opts to w and returns how
+// many there were.
+func exportCommands(w io.Writer, format string, opts database.SearchOptions) (int, error) {
+	count := 0
+
+	switch format {
+	case "json", "jsonl":
+		err := database.EachCommand(opts, func(c database.Command) error {
+			cmdJSON, err := commandToJSON(c)
+			if err != nil {
+				return err
+			}
+
+			var data []byte
+			if format == "jsonl" {
+				data, err = json.Marshal(cmdJSON)
+				data = append(data, '\n')
+			} else {
+				// Stream the array one element at a time
+				data, err = json.MarshalIndent(cmdJSON, "  ", "  ")
+				separator := ",\n  "
+				if count == 0 {
+					separator = "[\n  "
+				}
+				data = append([]byte(separator), data...)
+			}
+			if err != nil {
+				return err
+			}
+
+			count++
+			_, err = w.Write(data)
+			return err
+		})
+		if err != nil || format == "jsonl" {
+			return count, err
+		}
+		if count == 0 {
+			_, err = io.WriteString(w, "[]\n")
+		} else {
+			_, err = io.WriteString(w, "\n]\n")
+		}
+		return count, err
+
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"id", "timestamp", "start_time", "duration_ms", "exit_code", "directory", "session_id", "source", "command"})
+		err := database.EachCommand(opts, func(c database.Command) error {
+			duration := ""
+			if c.DurationMs.Valid {
+				duration = strconv.FormatInt(c.DurationMs.Int64, 10)
+			}
+			count++
+			return cw.Write([]string{strconv.Itoa(c.ID), c.Timestamp, c.StartTime.String, duration,
+				c.ExitCode, c.Directory, c.SessionID.String, c.Source.String, c.Command})
+		})
+		cw.Flush()
+		if err != nil {
+			return count, err
+		}
+		return count, cw.Error()
+
+	default:
+		hw, err := histfile.NewWriter(format, w)
+		if err != nil {
+			return 0, err
+		}
+		err = database.EachCommand(opts, func(c database.Command) error {
+			count++
+			return hw.Write(historyEntry(c))
+		})
+		if err != nil {
+			return count, err
+		}
+		return count, hw.Flush()
+	}
+}
+
+// historyEntry converts a stored command for a shell history file, dating it
+// by when it started if that is known.
+func historyEntry(c database.Command) histfile.Entry {
+	entry := histfile.Entry{Command: c.Command, Duration: -1}
+
+	if c.StartTime.Valid {
+		entry.Time, _ = time.ParseInLocation(database.StartTimeFormat, c.StartTime.String, time.Local)
+	}
+	if entry.Time.IsZero() {
+		entry.Time, _ = time.ParseInLocation(database.TimestampFormat, c.Timestamp, time.Local)
+	}
+	if c.DurationMs.Valid {
+		entry.Duration = time.Duration(c.DurationMs.Int64) * time.Millisecond
+	}
+	return entry
+}
+
+func init() {
+	addSearchFilterFlags(exportCmd)
+	exportCmd.Flags().Int("limit", 0, "Export only the newest N commands (0 for all)")
+	exportCmd.Flags().String("format", "jsonl", "Output format: "+strings.Join(exportFormats, ", "))
+	exportCmd.Flags().StringP("output", "o", "", "Write to this file instead of stdout")
+	rootCmd.AddCommand(exportCmd)
+}
@@ -0,0 +1,276 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Hooks are written between these marker lines so they can be found,
+// upgraded and removed without parsing the shell code inside.
+const (
+	hookBeginMarker = "# >>> cmdo >>>"
+	hookEndMarker   = "# <<< cmdo <<<"
+
+	// hookVersion is bumped whenever a hook template changes, so installed
+	// hooks can be recognized as outdated.
+	hookVersion = 3
+
+	// legacyHookMarker starts hooks installed before the block markers.
+	legacyHookMarker = "CMDO Command Logger Hook"
+)
+
+// renderHookBlock wraps a hook script in the block markers.
+func renderHookBlock(script string) string {
+	return hookBeginMarker + "\n" +
+		fmt.Sprintf("# Managed by 'cmdo setup' (hook v%d); changes inside this block are overwritten\n", hookVersion) +
+		strings.Trim(script, "\n") + "\n" +
+		hookEndMarker + "\n"
+}
+
+// findHookBlock returns the byte range of the marked hook block in content,
+// including the end marker's newline.
+func findHookBlock(content string) (int, int, bool) {
+	begin := markerLine(content, hookBeginMarker, 0)
+	if begin < 0 {
+		return 0, 0, false
+	}
+	end := markerLine(content, hookEndMarker, begin)
+	if end < 0 {
+		return 0, 0, false
+	}
+
+	end += len(hookEndMarker)
+	if strings.HasPrefix(content[end:], "\r\n") {
+		end += 2
+	} else if strings.HasPrefix(content[end:], "\n") {
+		end++
+	}
+	return begin, end, true
+}
+
+// markerLine finds a line consisting of marker at or after offset.
+func markerLine(content, marker string, offset int) int {
+	for offset <= len(content) {
+		i := strings.Index(content[offset:], marker)
+		if i < 0 {
+			return -1
+		}
+		i += offset
+		atLineStart := i == 0 || content[i-1] == '\n'
+		rest := content[i+len(marker):]
+		atLineEnd := rest == "" || rest[0] == '\n' || rest[0] == '\r'
+		if atLineStart && atLineEnd {
+			return i
+		}
+		offset = i + len(marker)
+	}
+	return -1
+}
+
+// installedHookBlock returns the marked hook block in content, if any.
+func installedHookBlock(content string) (string, bool) {
+	begin, end, ok := findHookBlock(content)
+	if !ok {
+		return "", false
+	}
+	return content[begin:end], true
+}
+
+// installHook returns content with block installed and what was done: the
+// block is replaced in place when present, a hook from before the markers is
+// removed and the block appended, and otherwise the block is appended.
+func installHook(path, content, block string) (string, string, error) {
+	if begin, end, ok := findHookBlock(content); ok {
+		if content[begin:end] == block {
+			return content, "unchanged", nil
+		}
+		return content[:begin] + block + content[end:], "updated", nil
+	}
+
+	status := "added"
+	if strings.Contains(content, legacyHookMarker) {
+		cleaned, err := removeLegacyHook(path, content)
+		if err != nil {
+			return "", "", err
+		}
+		content = cleaned
+		status = "upgraded"
+	}
+
+	content = strings.TrimRight(content, "\r\n")
+	if content != "" {
+		content += "\n\n"
+	}
+	return content + block, status, nil
+}
+
+// removeHook returns content without the cmdo hook and whether there was one.
+func removeHook(path, content string) (string, bool, error) {
+	if begin, end, ok := findHookBlock(content); ok {
+		return cleanupExcessiveNewlines(content[:begin] + content[end:]), true, nil
+	}
+	if strings.Contains(content, legacyHookMarker) {
+		cleaned, err := removeLegacyHook(path, content)
+		return cleaned, err == nil, err
+	}
+	return content, false, nil
+}
+
+// writeHookFile replaces the contents of an rc file, keeping a timestamped
+// copy of the previous version next to it. A file left empty is deleted
+// when it belongs to cmdo (the fish conf.d snippet).
+func writeHookFile(path, content string) (string, error) {
+	backup := ""
+	if original, err := os.ReadFile(path); err == nil {
+		backup = fmt.Sprintf("%s.cmdo-backup-%s", path, time.Now().Format("20060102-150405"))
+		if err := os.WriteFile(backup, original, 0644); err != nil {
+			return "", fmt.Errorf("error backing up %s: %w", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	if strings.TrimSpace(content) == "" && filepath.Base(path) == "cmdo.fish" {
+		return backup, os.Remove(path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return backup, err
+	}
+	return backup, os.WriteFile(path, []byte(content), 0644)
+}
+
+// unifiedDiff renders the line changes from before to after in unified diff
+// format with three lines of context.
+func unifiedDiff(name, before, after string) string {
+	a := splitLines(before)
+	b := splitLines(after)
+
+	// Longest common subsequence table, filled from the end
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type diffLine struct {
+		op   byte
+		text string
+	}
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j]})
+			j++
+		}
+	}
+
+	const context = 3
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", name, name)
+
+	oldLine, newLine := 1, 1
+	for k := 0; k < len(lines); {
+		if lines[k].op == ' ' {
+			oldLine++
+			newLine++
+			k++
+			continue
+		}
+
+		// Grow the hunk until changes are more than 2*context lines apart
+		start := max(k-context, 0)
+		end := k
+		for end < len(lines) {
+			if lines[end].op != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(lines) && lines[run].op == ' ' {
+				run++
+			}
+			if run == len(lines) || run-end > 2*context {
+				end = min(end+context, len(lines))
+				break
+			}
+			end = run
+		}
+
+		hunkOld, hunkNew := oldLine-(k-start), newLine-(k-start)
+		var oldCount, newCount int
+		var body strings.Builder
+		for _, l := range lines[start:end] {
+			body.WriteByte(l.op)
+			body.WriteString(l.text)
+			body.WriteByte('\n')
+			if l.op != '+' {
+				oldCount++
+			}
+			if l.op != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n%s", hunkOld, oldCount, hunkNew, newCount, body.String())
+
+		oldLine = hunkOld + oldCount
+		newLine = hunkNew + newCount
+		k = end
+	}
+
+	return out.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+var (
+	hookVersionPattern = regexp.MustCompile(`\(hook v(\d+)\)`)
+	hookBinaryPattern  = regexp.MustCompile(`["']([^"'\n]+)["'] (?:log|session new)\b`)
+)
+
+// hookBlockVersion returns the hook version recorded in an installed block,
+// or 0 if there is none.
+func hookBlockVersion(block string) int {
+	m := hookVersionPattern.FindStringSubmatch(block)
+	if m == nil {
+		return 0
+	}
+	version, _ := strconv.Atoi(m[1])
+	return version
+}
+
+// hookBinaryPath returns the cmdo binary an installed hook calls.
+func hookBinaryPath(hook string) string {
+	m := hookBinaryPattern.FindStringSubmatch(hook)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
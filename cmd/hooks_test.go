@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+const testBlock = hookBeginMarker + "\necho hook\n" + hookEndMarker + "\n"
+
+func TestFindHookBlock(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantBlock string
+		wantOK    bool
+	}{
+		{"no block", "alias ll='ls -l'\n", "", false},
+		{"only the block", testBlock, testBlock, true},
+		{"block between lines", "a\n" + testBlock + "b\n", testBlock, true},
+		{"block at end without newline", "a\n" + strings.TrimSuffix(testBlock, "\n"), strings.TrimSuffix(testBlock, "\n"), true},
+		{"crlf line endings", "a\r\n" + hookBeginMarker + "\r\necho hook\r\n" + hookEndMarker + "\r\nb\r\n",
+			hookBeginMarker + "\r\necho hook\r\n" + hookEndMarker + "\r\n", true},
+		{"missing end marker", "a\n" + hookBeginMarker + "\necho hook\n", "", false},
+		{"markers inside other lines", "echo '" + hookBeginMarker + "'\necho '" + hookEndMarker + "'\n", "", false},
+		{"end marker before begin", hookEndMarker + "\n" + hookBeginMarker + "\n", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			begin, end, ok := findHookBlock(tt.content)
+			if ok != tt.wantOK {
+				t.Fatalf("found = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && tt.content[begin:end] != tt.wantBlock {
+				t.Errorf("block = %q, want %q", tt.content[begin:end], tt.wantBlock)
+			}
+		})
+	}
+}
+
+func TestInstallHook(t *testing.T) {
+	newBlock := renderHookBlock("echo new")
+
+	tests := []struct {
+		name       string
+		path       string
+		content    string
+		want       string
+		wantStatus string
+	}{
+		{"empty file", "/home/me/.bashrc", "", newBlock, "added"},
+		{"appended after a blank line", "/home/me/.bashrc", "alias ll='ls -l'\n\n\n", "alias ll='ls -l'\n\n" + newBlock, "added"},
+		{"replaced in place", "/home/me/.bashrc", "a\n" + testBlock + "b\n", "a\n" + newBlock + "b\n", "updated"},
+		{"already current", "/home/me/.bashrc", "a\n" + newBlock, "a\n" + newBlock, "unchanged"},
+		{
+			"legacy hook upgraded",
+			"/home/me/.bashrc",
+			"alias ll='ls -l'\n\n# CMDO Command Logger Hook\nfunction __cmdo_log() {\n    true\n}\nif true; then\n    PROMPT_COMMAND=__cmdo_log\nfi\n",
+			"alias ll='ls -l'\n\n" + newBlock,
+			"upgraded",
+		},
+		{"legacy fish file replaced", "/home/me/.config/fish/conf.d/cmdo.fish", "# CMDO Command Logger Hook\nfunction x\nend\n", newBlock, "upgraded"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, status, err := installHook(tt.path, tt.content, newBlock)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", status, tt.wantStatus)
+			}
+			if got != tt.want {
+				t.Errorf("content =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveHook(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		content     string
+		want        string
+		wantRemoved bool
+	}{
+		{"no hook", "/home/me/.zshrc", "alias ll='ls -l'\n", "alias ll='ls -l'\n", false},
+		{"block", "/home/me/.zshrc", "a\n\n" + testBlock + "\nb\n", "a\n\nb\n", true},
+		{"only the block", "/home/me/.zshrc", testBlock, "\n", true},
+		{
+			"legacy zsh hook",
+			"/home/me/.zshrc",
+			"a\n\n# CMDO Command Logger Hook\nautoload -Uz add-zsh-hook\nfunction __cmdo_precmd() {\n    true\n}\nadd-zsh-hook precmd __cmdo_precmd\n",
+			"a\n",
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, removed, err := removeHook(tt.path, tt.content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if removed != tt.wantRemoved {
+				t.Errorf("removed = %v, want %v", removed, tt.wantRemoved)
+			}
+			if got != tt.want {
+				t.Errorf("content =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   string
+	}{
+		{
+			name:   "added to empty file",
+			before: "",
+			after:  "a\nb\n",
+			want:   "@@ -1,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name:   "changed line with context",
+			before: "1\n2\n3\n4\n5\n6\n7\n8\n",
+			after:  "1\n2\n3\n4\nfive\n6\n7\n8\n",
+			want:   "@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name:   "distant changes make two hunks",
+			before: "a\n1\n2\n3\n4\n5\n6\n7\n8\nb\n",
+			after:  "A\n1\n2\n3\n4\n5\n6\n7\n8\nB\n",
+			want:   "@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n@@ -7,4 +7,4 @@\n 6\n 7\n 8\n-b\n+B\n",
+		},
+		{
+			name:   "removed everything",
+			before: "a\n",
+			after:  "",
+			want:   "@@ -1,1 +1,0 @@\n-a\n",
+		},
+		{
+			name:   "no changes",
+			before: "a\nb\n",
+			after:  "a\nb\n",
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := "--- .bashrc\n+++ .bashrc\n" + tt.want
+			if got := unifiedDiff(".bashrc", tt.before, tt.after); got != want {
+				t.Errorf("diff =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestHookTemplates(t *testing.T) {
+	const binary = "/opt/cmdo/bin/cmdo"
+
+	tests := []struct {
+		shell  string
+		script string
+	}{
+		{"bash", getBashHook(binary, true)},
+		{"zsh", getZshHook(binary, true)},
+		{"fish", getFishHook(binary, true)},
+		{"powershell", getPowerShellHook(binary, true)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			block := renderHookBlock(tt.script)
+			if got := hookBlockVersion(block); got != hookVersion {
+				t.Errorf("hookBlockVersion = %d, want %d", got, hookVersion)
+			}
+			if got := hookBinaryPath(block); got != binary {
+				t.Errorf("hookBinaryPath = %q, want %q", got, binary)
+			}
+			for _, flag := range []string{"--session ", "--session-start ", "--shell " + tt.shell} {
+				if !strings.Contains(block, flag) {
+					t.Errorf("hook doesn't pass %s", flag)
+				}
+			}
+			if _, _, ok := findHookBlock("a\n" + block); !ok {
+				t.Error("rendered block isn't found again")
+			}
+		})
+	}
+}
+
+func TestHookBlockVersion(t *testing.T) {
+	tests := []struct {
+		block string
+		want  int
+	}{
+		{"# Managed by 'cmdo setup' (hook v2); changes inside this block are overwritten", 2},
+		{"# Managed by 'cmdo setup' (hook v12)", 12},
+		{"# Managed by 'cmdo setup'", 0},
+	}
+
+	for _, tt := range tests {
+		if got := hookBlockVersion(tt.block); got != tt.want {
+			t.Errorf("hookBlockVersion(%q) = %d, want %d", tt.block, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tanu2534/cmdo/config"
+	"github.com/tanu2534/cmdo/ignore"
+)
+
+var ignoreCmd = &cobra.Command{
+	Use:   "ignore [command]",
+	Short: "Show or test the rules for commands that are never logged",
+	Long: `ignore lists the patterns 'cmdo log' uses to skip commands, or, given a command line, tells whether it would be skipped.
+
+Patterns are set in the ignore.patterns config key, e.g. cmdo config set ignore.patterns '["ls", "cd *"]':
+
+  ls                 glob on the whole command line (* and ? wildcards)
+  cd *               matches "cd" with any arguments
+  re:^git (st|diff)  regular expression searched in the command line
+  dir:~/secret/**    glob on the working directory, including the directory itself
+
+Commands typed with a leading space are skipped, like bash's HISTCONTROL=ignorespace, unless ignore.ignore_space is false.
+Use 'cmdo pause' to stop logging in one shell for a while.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		pwd, _ := cmd.Flags().GetString("pwd")
+
+		if len(args) == 1 {
+			rules, err := loadIgnoreRules()
+			if err != nil {
+				fmt.Println("❌", err)
+				os.Exit(1)
+			}
+
+			if reason, ignored := rules.Match(args[0], pwd); ignored {
+				fmt.Printf("🙈 Ignored (%s)\n", reason)
+			} else {
+				fmt.Println("📝 Logged")
+			}
+			return
+		}
+
+		patterns := config.Get().Ignore.Patterns
+		fmt.Printf("Ignore patterns (%s):\n\n", config.Path())
+		if len(patterns) == 0 {
+			fmt.Println("   (none)")
+		}
+		for _, p := range patterns {
+			fmt.Println("  ", p)
+		}
+	},
+}
+
+func loadIgnoreRules() (*ignore.Rules, error) {
+	cfg := config.Get().Ignore
+
+	home, _ := os.UserHomeDir()
+	rules, err := ignore.Parse(cfg.Patterns, home)
+	if err != nil {
+		return nil, err
+	}
+	rules.IgnoreSpace = cfg.IgnoreSpace
+	return rules, nil
+}
+
+func init() {
+	cwd, _ := os.Getwd()
+	ignoreCmd.Flags().String("pwd", cwd, "Working directory to test the command in")
+	rootCmd.AddCommand(ignoreCmd)
+}
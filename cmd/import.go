@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tanu2534/cmdo/database"
+	"github.com/tanu2534/cmdo/histfile"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <format> <file>",
+	Short: "Import an existing shell history file",
+	Long: `import adds the commands in a shell history file to cmdo. Formats:
+
+  bash        ~/.bash_history, with or without HISTTIMEFORMAT timestamps
+  zsh         ~/.zsh_history, plain or EXTENDED_HISTORY (with start time and duration)
+  fish        ~/.local/share/fish/fish_history
+  powershell  PSReadLine's ConsoleHost_history.txt
+
+History files don't record exit codes or directories, so imported commands have an unknown exit code and no directory.
+Commands without a timestamp are dated by the file's modification time. Commands already in the database are
+skipped, so importing the same file again is harmless. Ignore rules and redaction apply as for 'cmdo log'.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		format, path := importFormat(args[0]), args[1]
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		if !slices.Contains(histfile.ImportFormats, format) {
+			fmt.Printf("❌ Unknown format %q, use one of: %s\n", args[0], strings.Join(histfile.ImportFormats, ", "))
+			os.Exit(1)
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Println("❌ Error opening history file:", err)
+			os.Exit(1)
+		}
+		defer file.Close()
+
+		info, err := file.Stat()
+		if err != nil {
+			fmt.Println("❌ Error reading history file:", err)
+			os.Exit(1)
+		}
+
+		parsed, err := histfile.Parse(format, file)
+		if err != nil {
+			fmt.Println("❌ Error reading history file:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("📥 Read %d command(s) from %s\n", len(parsed), path)
+
+		entries, ignored, err := importEntries(parsed)
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+
+		if dryRun {
+			fmt.Printf("%d command(s) would be imported, %d ignored by ignore rules\n", len(entries), ignored)
+			return
+		}
+
+		database.InitDB(database.GetGlobalDBPath())
+		defer database.DB.Close()
+
+		result, err := database.ImportCommands(entries, "import:"+format, info.ModTime())
+		if err != nil {
+			fmt.Println("❌ Error importing commands:", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("✅ Imported %d command(s)\n", result.Imported)
+		if result.Duplicates > 0 {
+			fmt.Printf("⏭️  Skipped %d already in the database\n", result.Duplicates)
+		}
+		if ignored > 0 {
+			fmt.Printf("🙈 Skipped %d matching ignore rules\n", ignored)
+		}
+	},
+}
+
+// importFormat maps the export format names onto import formats.
+func importFormat(name string) string {
+	switch name {
+	case "bash_history":
+		return "bash"
+	case "zsh_extended":
+		return "zsh"
+	case "pwsh":
+		return "powershell"
+	}
+	return name
+}
+
+// importEntries applies the ignore rules and redaction to parsed history,
+// returning the entries to store and how many were ignored.
+func importEntries(parsed []histfile.Entry) ([]database.LogEntry, int, error) {
+	rules, err := loadIgnoreRules()
+	if err != nil {
+		return nil, 0, fmt.Errorf("error loading ignore rules: %w", err)
+	}
+	redactor, err := loadRedactor()
+	if err != nil {
+		return nil, 0, fmt.Errorf("error loading redaction rules: %w", err)
+	}
+
+	var entries []database.LogEntry
+	ignored := 0
+	for _, e := range parsed {
+		if _, skip := rules.Match(e.Command, ""); skip {
+			ignored++
+			continue
+		}
+
+		// History files don't record exit codes, so they stay unknown
+		entry := database.LogEntry{
+			Command:    redactor.Redact(e.Command),
+			StartTime:  e.Time,
+			DurationMs: -1,
+		}
+		if e.Duration >= 0 {
+			entry.DurationMs = e.Duration.Milliseconds()
+		}
+		entries = append(entries, entry)
+	}
+	return entries, ignored, nil
+}
+
+func init() {
+	importCmd.Flags().Bool("dry-run", false, "Parse the file and report counts without importing")
+	rootCmd.AddCommand(importCmd)
+}
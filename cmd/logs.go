@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
+	"os/user"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/tanu2534/cmdo/database"
@@ -13,38 +17,213 @@ var logCmd = &cobra.Command{
 	Use:     "log",
 	Aliases: []string{"logs"},
 	Short:   "Add log in the server",
-	Long:    "log command adds the log in the server additional flags are --command, --exit-code, --pwd",
+	Long: `log command adds the log in the server additional flags are --command, --exit-code, --pwd, --start, --duration, --session, --session-start, --tty, --shell
+
+log is meant to be called by the shell hooks and prints nothing unless --verbose is given, in which case it writes
+one JSON object to stderr describing the outcome, e.g. {"status":"logged","via":"daemon"} or
+{"status":"error","stage":"insert","error":"..."}. It exits with 0 when the command was logged or deliberately
+skipped (paused, ignored), 1 when it could not be stored and 2 for invalid flags.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		command, _ := cmd.Flags().GetString("command")
-		exitCode, _ := cmd.Flags().GetString("exit-code")
-		pwd, _ := cmd.Flags().GetString("pwd")
+		verbose, _ := cmd.Flags().GetBool("verbose")
+
+		event, exitCode := runLog(cmd)
+		if verbose {
+			json.NewEncoder(os.Stderr).Encode(event)
+		}
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	},
+}
+
+// logEvent is what `cmdo log --verbose` reports.
+type logEvent struct {
+	// Status is logged, paused, ignored or error
+	Status string `json:"status"`
+	// Via tells whether the daemon or cmdo log itself wrote the command
+	Via    string `json:"via,omitempty"`
+	Reason string `json:"reason,omitempty"`
+	// Stage is the step that failed: flags, config, database, insert or
+	// retention. A failed retention doesn't fail the log.
+	Stage string `json:"stage,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+func logError(stage string, err error) logEvent {
+	return logEvent{Status: "error", Stage: stage, Error: err.Error()}
+}
+
+// runLog logs the command described by the flags and returns what happened
+// along with the process exit code.
+func runLog(cmd *cobra.Command) (logEvent, int) {
+	command, _ := cmd.Flags().GetString("command")
+	exitCode, _ := cmd.Flags().GetString("exit-code")
+	pwd, _ := cmd.Flags().GetString("pwd")
+	start, _ := cmd.Flags().GetString("start")
+	durationMs, _ := cmd.Flags().GetInt64("duration")
+	sessionID, _ := cmd.Flags().GetString("session")
+	sessionStart, _ := cmd.Flags().GetString("session-start")
+	tty, _ := cmd.Flags().GetString("tty")
+	shell, _ := cmd.Flags().GetString("shell")
+
+	if command == "" || pwd == "" {
+		return logError("flags", fmt.Errorf("--command and --pwd are required")), 2
+	}
+	code, err := strconv.Atoi(strings.TrimSpace(exitCode))
+	if err != nil {
+		return logError("flags", fmt.Errorf("--exit-code must be an integer, got %q", exitCode)), 2
+	}
+	startTime, durationMs, err := resolveTiming(start, durationMs, time.Now())
+	if err != nil {
+		return logError("flags", fmt.Errorf("invalid --start: %w", err)), 2
+	}
+
+	// Without the config the redact and ignore rules and db_path are
+	// unknown, so store nothing rather than the raw command
+	if configErr != nil {
+		return logError("config", configErr), 1
+	}
+
+	if isLoggingPaused(sessionID) {
+		return logEvent{Status: "paused"}, 0
+	}
+
+	rules, err := loadIgnoreRules()
+	if err != nil {
+		return logError("config", err), 1
+	}
+	if reason, ignored := rules.Match(command, pwd); ignored {
+		return logEvent{Status: "ignored", Reason: reason}, 0
+	}
+
+	// Secrets never reach the database; fail closed if the custom
+	// patterns are broken rather than storing the raw command
+	redactor, err := loadRedactor()
+	if err != nil {
+		return logError("config", err), 1
+	}
 
-		if command != "" && pwd != "" {
-			// ✅ Use global DB path instead of ./cmdo.db
-			homeDir, err := os.UserHomeDir()
+	entry := database.LogEntry{
+		Command:    redactor.Redact(command),
+		ExitCode:   strconv.Itoa(code),
+		Directory:  pwd,
+		StartTime:  startTime,
+		DurationMs: durationMs,
+		LoggedAt:   time.Now(),
+	}
+	if sessionID != "" {
+		entry.Session = currentSession(sessionID, tty, shell)
+		if sessionStart != "" {
+			startedAt, err := parseStartTime(sessionStart)
 			if err != nil {
-				fmt.Println("Error getting home directory:", err)
-				return
+				return logError("flags", fmt.Errorf("invalid --session-start: %w", err)), 2
 			}
+			entry.Session.StartedAt = startedAt.Local().Format(database.TimestampFormat)
+		}
+	}
+
+	// The daemon, when running, does the writing (and retention)
+	if err := sendToDaemon(entry); err == nil {
+		return logEvent{Status: "logged", Via: "daemon"}, 0
+	}
 
-			// Create .cmdo directory if it doesn't exist
-			cmdoDir := filepath.Join(homeDir, ".cmdo")
-			os.MkdirAll(cmdoDir, 0755)
+	if err := database.OpenDB(database.GetGlobalDBPath()); err != nil {
+		return logError("database", err), 1
+	}
+	defer database.DB.Close()
+	if _, err := database.Migrate(); err != nil {
+		return logError("database", err), 1
+	}
 
-			dbPath := filepath.Join(cmdoDir, "cmdo.db")
+	if err := database.InsertCmd(entry); err != nil {
+		return logError("insert", err), 1
+	}
 
-			database.InitDB(dbPath)
-			defer database.DB.Close()
+	if err := applyRetention(); err != nil {
+		event := logError("retention", err)
+		event.Status = "logged"
+		event.Via = "database"
+		return event, 0
+	}
+	return logEvent{Status: "logged", Via: "database"}, 0
+}
 
-			fmt.Println(command, exitCode, pwd)
-			database.InsertCmd(command, exitCode, pwd)
+// resolveTiming works out the start time and duration of a command from
+// whatever the hook could provide. Hooks that only know the start time
+// (bash, zsh) get a duration measured up to now; hooks that only know the
+// duration (fish) get a start time counted back from now. A zero time and a
+// negative duration mean unknown.
+func resolveTiming(start string, durationMs int64, now time.Time) (time.Time, int64, error) {
+	var startTime time.Time
+	if start != "" {
+		var err error
+		startTime, err = parseStartTime(start)
+		if err != nil {
+			return time.Time{}, -1, err
 		}
-	},
+	}
+
+	switch {
+	case !startTime.IsZero() && durationMs < 0:
+		durationMs = now.Sub(startTime).Milliseconds()
+		if durationMs < 0 {
+			durationMs = 0
+		}
+	case startTime.IsZero() && durationMs >= 0:
+		startTime = now.Add(-time.Duration(durationMs) * time.Millisecond)
+	}
+
+	return startTime, durationMs, nil
+}
+
+// parseStartTime accepts Unix epoch seconds with an optional fraction, as
+// produced by $EPOCHREALTIME (which uses a comma in some locales), or an
+// RFC 3339 timestamp, as produced by PowerShell's ToString('o').
+func parseStartTime(start string) (time.Time, error) {
+	epoch := strings.Replace(start, ",", ".", 1)
+	if seconds, err := strconv.ParseFloat(epoch, 64); err == nil {
+		whole := int64(seconds)
+		nanos := int64((seconds - float64(whole)) * float64(time.Second))
+		return time.Unix(whole, nanos), nil
+	}
+
+	return time.Parse(time.RFC3339Nano, start)
+}
+
+// currentSession describes the shell session the hook runs in. Hostname and
+// user come from the environment of the cmdo process, which the hook shares.
+func currentSession(id, tty, shell string) database.Session {
+	hostname, _ := os.Hostname()
+
+	username := os.Getenv("USER")
+	if username == "" {
+		username = os.Getenv("USERNAME")
+	}
+	if username == "" {
+		if u, err := user.Current(); err == nil {
+			username = u.Username
+		}
+	}
+
+	return database.Session{
+		ID:       id,
+		Hostname: hostname,
+		User:     username,
+		TTY:      tty,
+		Shell:    shell,
+	}
 }
 
 func init() {
 	logCmd.Flags().String("command", "", "Command that was executed")
 	logCmd.Flags().String("exit-code", "", "Exit code of the command")
 	logCmd.Flags().String("pwd", "", "Working directory of the command")
+	logCmd.Flags().String("start", "", "Start time of the command (Unix seconds or RFC 3339)")
+	logCmd.Flags().Int64("duration", -1, "Duration of the command in milliseconds")
+	logCmd.Flags().String("session", "", "ID of the shell session the command ran in")
+	logCmd.Flags().String("session-start", "", "When the shell session started (Unix seconds or RFC 3339)")
+	logCmd.Flags().String("tty", "", "Terminal device of the shell session")
+	logCmd.Flags().String("shell", "", "Shell type (bash, zsh, fish, powershell)")
+	logCmd.Flags().BoolP("verbose", "v", false, "Report the outcome as JSON on stderr")
 	rootCmd.AddCommand(logCmd)
 }
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseStartTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		start   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"epoch seconds", "1700000000", time.Unix(1700000000, 0), false},
+		{"epoch with fraction", "1700000000.250000", time.Unix(1700000000, 250*int64(time.Millisecond)), false},
+		{"epoch with comma", "1700000000,5", time.Unix(1700000000, 500*int64(time.Millisecond)), false},
+		{"rfc 3339", "2023-11-14T22:13:20Z", time.Unix(1700000000, 0), false},
+		{"rfc 3339 with offset and fraction", "2023-11-14T23:13:20.5+01:00", time.Unix(1700000000, 500*int64(time.Millisecond)), false},
+		{"garbage", "yesterday", time.Time{}, true},
+		{"two commas", "1700000000,5,5", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStartTime(tt.start)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseStartTime(%q) error = %v, wantErr %v", tt.start, err, tt.wantErr)
+			}
+			// Float parsing may be off by a few nanoseconds
+			if !tt.wantErr && got.Sub(tt.want).Abs() > time.Microsecond {
+				t.Errorf("parseStartTime(%q) = %v, want %v", tt.start, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveTiming(t *testing.T) {
+	now := time.Unix(1700000100, 0)
+
+	tests := []struct {
+		name         string
+		start        string
+		durationMs   int64
+		wantStart    time.Time
+		wantDuration int64
+		wantErr      bool
+	}{
+		{"nothing known", "", -1, time.Time{}, -1, false},
+		{"start only", "1700000090", -1, time.Unix(1700000090, 0), 10000, false},
+		{"start with comma decimals", "1700000099,75", -1, time.Unix(1700000099, 750*int64(time.Millisecond)), 250, false},
+		{"start in the future", "1700000200", -1, time.Unix(1700000200, 0), 0, false},
+		{"duration only", "", 1500, time.Unix(1700000098, 500*int64(time.Millisecond)), 1500, false},
+		{"zero duration", "", 0, now, 0, false},
+		{"both known", "1700000000", 42, time.Unix(1700000000, 0), 42, false},
+		{"invalid start", "soon", -1, time.Time{}, -1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, duration, err := resolveTiming(tt.start, tt.durationMs, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if start.Sub(tt.wantStart).Abs() > time.Microsecond || start.IsZero() != tt.wantStart.IsZero() {
+				t.Errorf("start = %v, want %v", start, tt.wantStart)
+			}
+			if duration != tt.wantDuration {
+				t.Errorf("duration = %d, want %d", duration, tt.wantDuration)
+			}
+		})
+	}
+}
+
+func TestRunLogBrokenConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configErr = errors.New("unknown key \"bogus\"")
+	t.Cleanup(func() { configErr = nil })
+
+	for name, value := range map[string]string{"command": "deploy corp-123456", "exit-code": "0", "pwd": "/work"} {
+		if err := logCmd.Flags().Set(name, value); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { logCmd.Flags().Set(name, "") })
+	}
+
+	event, exitCode := runLog(logCmd)
+	if exitCode == 0 || event.Stage != "config" {
+		t.Errorf("runLog = %+v, exit %d; want a config error", event, exitCode)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".cmdo")); !os.IsNotExist(err) {
+		t.Errorf("the default database was touched: %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"github.com/tanu2534/cmdo/config"
+)
+
+// allSessions in paused_sessions pauses logging in every shell.
+const allSessions = "*"
+
+var pauseCmd = &cobra.Command{
+	Use:   "pause",
+	Short: "Stop logging commands in this shell",
+	Long:  "pause stops 'cmdo log' from recording commands run in the current shell until 'cmdo resume'. The shell is identified by $CMDO_SESSION, which the hooks set; use --all to pause every shell.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		all, _ := cmd.Flags().GetBool("all")
+		session, _ := cmd.Flags().GetString("session")
+
+		target, err := pauseTarget(all, session)
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+
+		paused, err := readPausedSessions()
+		if err != nil {
+			fmt.Println("❌ Error reading pause state:", err)
+			os.Exit(1)
+		}
+		if !slices.Contains(paused, target) {
+			paused = append(paused, target)
+		}
+		if err := writePausedSessions(paused); err != nil {
+			fmt.Println("❌ Error saving pause state:", err)
+			os.Exit(1)
+		}
+
+		if target == allSessions {
+			fmt.Println("⏸️  Logging paused in all shells. Run 'cmdo resume --all' to resume.")
+		} else {
+			fmt.Println("⏸️  Logging paused in this shell. Run 'cmdo resume' to resume.")
+		}
+	},
+}
+
+var resumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Resume logging commands in this shell",
+	Long:  "resume undoes 'cmdo pause' for the current shell, or with --all clears every pause.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		all, _ := cmd.Flags().GetBool("all")
+		session, _ := cmd.Flags().GetString("session")
+
+		target, err := pauseTarget(all, session)
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+
+		paused, err := readPausedSessions()
+		if err != nil {
+			fmt.Println("❌ Error reading pause state:", err)
+			os.Exit(1)
+		}
+
+		if target == allSessions {
+			paused = nil
+		} else {
+			paused = slices.DeleteFunc(paused, func(s string) bool { return s == target })
+		}
+		if err := writePausedSessions(paused); err != nil {
+			fmt.Println("❌ Error saving pause state:", err)
+			os.Exit(1)
+		}
+
+		if slices.Contains(paused, allSessions) {
+			fmt.Println("⚠️  Logging is still paused in all shells. Run 'cmdo resume --all' to resume.")
+			return
+		}
+		fmt.Println("▶️  Logging resumed")
+	},
+}
+
+func pauseTarget(all bool, session string) (string, error) {
+	if all {
+		return allSessions, nil
+	}
+	if session == "" {
+		return "", fmt.Errorf("no shell session found; run this from a shell with the cmdo hook installed, or use --all")
+	}
+	return session, nil
+}
+
+func readPausedSessions() ([]string, error) {
+	cfg, err := config.LoadFile()
+	if err != nil {
+		return nil, err
+	}
+	return cfg.PausedSessions, nil
+}
+
+func writePausedSessions(sessions []string) error {
+	return config.Update(func(cfg *config.Config) error {
+		cfg.PausedSessions = sessions
+		return nil
+	})
+}
+
+// isLoggingPaused reports whether logging is paused for the given session or
+// for every shell.
+func isLoggingPaused(session string) bool {
+	paused := config.Get().PausedSessions
+	return slices.Contains(paused, allSessions) || (session != "" && slices.Contains(paused, session))
+}
+
+func init() {
+	for _, c := range []*cobra.Command{pauseCmd, resumeCmd} {
+		c.Flags().Bool("all", false, "Apply to every shell instead of only the current one")
+		c.Flags().String("session", os.Getenv("CMDO_SESSION"), "Session ID of the shell (defaults to $CMDO_SESSION)")
+		rootCmd.AddCommand(c)
+	}
+}
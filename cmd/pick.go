@@ -0,0 +1,428 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"github.com/tanu2534/cmdo/database"
+	"golang.org/x/term"
+)
+
+// pickCandidate is one distinct command line in the picker, described by its
+// most recent run.
+type pickCandidate struct {
+	Command  string
+	ExitCode string
+	LastRun  time.Time
+	InPwd    bool
+	score    int
+}
+
+var pickCmd = &cobra.Command{
+	Use:   "pick",
+	Short: "Interactively pick a command from history",
+	Long: `pick opens a fuzzy finder over the command history and prints the chosen
+command to stdout, so shells can insert it into the prompt. Commands run in
+--pwd are listed first.
+
+Keys: type to filter, Up/Down or Ctrl-P/Ctrl-N to move, Enter to select,
+Esc or Ctrl-C to cancel (exit status 1).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		pwd, _ := cmd.Flags().GetString("pwd")
+		query, _ := cmd.Flags().GetString("query")
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		if pwd == "" {
+			pwd, _ = os.Getwd()
+		}
+
+		// InitDB exits with status 1, which means "cancelled" to the
+		// keybindings, so report database errors with status 2 instead
+		if err := database.OpenDB(database.GetGlobalDBPath()); err != nil {
+			fmt.Fprintln(os.Stderr, "Error opening database:", err)
+			os.Exit(2)
+		}
+		if _, err := database.Migrate(); err != nil {
+			fmt.Fprintln(os.Stderr, "Error migrating database:", err)
+			os.Exit(2)
+		}
+		commands, err := database.RecentCommands(limit)
+		database.DB.Close()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error loading history:", err)
+			os.Exit(2)
+		}
+
+		selected, ok, err := runPicker(buildPickCandidates(commands, pwd), query)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(2)
+		}
+		if !ok {
+			os.Exit(1)
+		}
+		fmt.Println(selected)
+	},
+}
+
+// buildPickCandidates collapses history into one entry per command line,
+// keeping the newest run. commands must be ordered newest first.
+func buildPickCandidates(commands []database.Command, pwd string) []pickCandidate {
+	index := make(map[string]int)
+	var candidates []pickCandidate
+
+	for _, c := range commands {
+		i, seen := index[c.Command]
+		if !seen {
+			lastRun, _ := time.ParseInLocation(database.TimestampFormat, c.Timestamp, time.Local)
+			index[c.Command] = len(candidates)
+			candidates = append(candidates, pickCandidate{
+				Command:  c.Command,
+				ExitCode: c.ExitCode,
+				LastRun:  lastRun,
+			})
+			i = len(candidates) - 1
+		}
+		if c.Directory == pwd {
+			candidates[i].InPwd = true
+		}
+	}
+
+	return candidates
+}
+
+// filterPickCandidates returns the candidates matching query, best first.
+// Ties keep history order, so recent commands win.
+func filterPickCandidates(candidates []pickCandidate, query string) []pickCandidate {
+	var matches []pickCandidate
+	for _, c := range candidates {
+		score := 0
+		if query != "" {
+			score = fuzzyScore(query, c.Command)
+			if score < 0 {
+				continue
+			}
+		}
+		if c.InPwd {
+			score += 10
+		}
+		c.score = score
+		matches = append(matches, c)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	return matches
+}
+
+// fuzzyScore reports how well pattern matches s as a case-insensitive
+// subsequence, or -1 if it doesn't. Consecutive matches and matches at the
+// start of a word score higher; a literal substring match scores highest.
+func fuzzyScore(pattern, s string) int {
+	p := []rune(strings.ToLower(pattern))
+	str := []rune(strings.ToLower(s))
+
+	score := 0
+	pi := 0
+	lastMatch := -1
+	for si := 0; si < len(str) && pi < len(p); si++ {
+		if str[si] != p[pi] {
+			continue
+		}
+		score++
+		if lastMatch == si-1 {
+			score += 5
+		}
+		if si == 0 || strings.ContainsRune(" /\\-_.:=", str[si-1]) {
+			score += 3
+		}
+		if lastMatch >= 0 {
+			score -= min(si-lastMatch-1, 3)
+		}
+		lastMatch = si
+		pi++
+	}
+	if pi < len(p) {
+		return -1
+	}
+
+	if strings.Contains(string(str), string(p)) {
+		score += 20
+	}
+	return score
+}
+
+// openTTY opens the controlling terminal directly, since stdout is captured
+// by the shell keybinding that runs `cmdo pick`.
+func openTTY() (in *os.File, out *os.File, err error) {
+	if runtime.GOOS == "windows" {
+		in, err = os.OpenFile("CONIN$", os.O_RDWR, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		out, err = os.OpenFile("CONOUT$", os.O_RDWR, 0)
+		if err != nil {
+			in.Close()
+			return nil, nil, err
+		}
+		return in, out, nil
+	}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tty, tty, nil
+}
+
+const (
+	keyNone = iota
+	keyUp
+	keyDown
+	keyEnter
+	keyCancel
+	keyBackspace
+	keyClearLine
+	keyDeleteWord
+	keyRune
+)
+
+type keyEvent struct {
+	kind int
+	r    rune
+}
+
+// parseKeys decodes one read from a raw terminal into key events.
+func parseKeys(b []byte) []keyEvent {
+	var keys []keyEvent
+	for len(b) > 0 {
+		switch {
+		case b[0] == 0x1b && len(b) == 1:
+			keys = append(keys, keyEvent{kind: keyCancel})
+			b = b[1:]
+		case b[0] == 0x1b && len(b) >= 3 && (b[1] == '[' || b[1] == 'O'):
+			switch b[2] {
+			case 'A':
+				keys = append(keys, keyEvent{kind: keyUp})
+			case 'B':
+				keys = append(keys, keyEvent{kind: keyDown})
+			}
+			b = b[3:]
+		case b[0] == 0x1b:
+			b = b[1:]
+		case b[0] == '\r' || b[0] == '\n':
+			keys = append(keys, keyEvent{kind: keyEnter})
+			b = b[1:]
+		case b[0] == 0x03 || b[0] == 0x07 || b[0] == 0x04:
+			keys = append(keys, keyEvent{kind: keyCancel})
+			b = b[1:]
+		case b[0] == 0x7f || b[0] == 0x08:
+			keys = append(keys, keyEvent{kind: keyBackspace})
+			b = b[1:]
+		case b[0] == 0x10:
+			keys = append(keys, keyEvent{kind: keyUp})
+			b = b[1:]
+		case b[0] == 0x0e:
+			keys = append(keys, keyEvent{kind: keyDown})
+			b = b[1:]
+		case b[0] == 0x15:
+			keys = append(keys, keyEvent{kind: keyClearLine})
+			b = b[1:]
+		case b[0] == 0x17:
+			keys = append(keys, keyEvent{kind: keyDeleteWord})
+			b = b[1:]
+		case b[0] < 0x20:
+			b = b[1:]
+		default:
+			r, size := utf8.DecodeRune(b)
+			if r != utf8.RuneError && unicode.IsPrint(r) {
+				keys = append(keys, keyEvent{kind: keyRune, r: r})
+			}
+			b = b[size:]
+		}
+	}
+	return keys
+}
+
+// runPicker shows the picker on the terminal until the user selects a
+// command (ok is true) or cancels.
+func runPicker(candidates []pickCandidate, query string) (selected string, ok bool, err error) {
+	in, out, err := openTTY()
+	if err != nil {
+		return "", false, fmt.Errorf("no terminal available: %w", err)
+	}
+	defer in.Close()
+	if out != in {
+		defer out.Close()
+	}
+
+	oldState, err := term.MakeRaw(int(in.Fd()))
+	if err != nil {
+		return "", false, err
+	}
+	defer term.Restore(int(in.Fd()), oldState)
+
+	// Use the alternate screen so the shell's scrollback is left untouched
+	fmt.Fprint(out, "\x1b[?1049h")
+	defer fmt.Fprint(out, "\x1b[?1049l")
+
+	input := []rune(query)
+	cursor := 0
+	offset := 0
+	buf := make([]byte, 256)
+
+	for {
+		matches := filterPickCandidates(candidates, string(input))
+		if cursor >= len(matches) {
+			cursor = max(len(matches)-1, 0)
+		}
+
+		width, height, err := term.GetSize(int(out.Fd()))
+		if err != nil || width <= 0 || height <= 0 {
+			width, height = 80, 24
+		}
+		listHeight := max(height-2, 1)
+		if cursor < offset {
+			offset = cursor
+		} else if cursor >= offset+listHeight {
+			offset = cursor - listHeight + 1
+		}
+
+		renderPicker(out, matches, len(candidates), string(input), cursor, offset, width, listHeight)
+
+		n, err := in.Read(buf)
+		if err != nil {
+			return "", false, err
+		}
+
+		for _, key := range parseKeys(buf[:n]) {
+			switch key.kind {
+			case keyCancel:
+				return "", false, nil
+			case keyEnter:
+				if len(matches) == 0 {
+					return "", false, nil
+				}
+				return matches[cursor].Command, true, nil
+			case keyUp:
+				if cursor > 0 {
+					cursor--
+				}
+			case keyDown:
+				if cursor < len(matches)-1 {
+					cursor++
+				}
+			case keyBackspace:
+				if len(input) > 0 {
+					input = input[:len(input)-1]
+				}
+				cursor, offset = 0, 0
+			case keyClearLine:
+				input = input[:0]
+				cursor, offset = 0, 0
+			case keyDeleteWord:
+				trimmed := strings.TrimRight(string(input), " ")
+				if i := strings.LastIndex(trimmed, " "); i >= 0 {
+					input = []rune(trimmed[:i+1])
+				} else {
+					input = input[:0]
+				}
+				cursor, offset = 0, 0
+			case keyRune:
+				input = append(input, key.r)
+				cursor, offset = 0, 0
+			}
+		}
+	}
+}
+
+func renderPicker(out *os.File, matches []pickCandidate, total int, query string, cursor, offset, width, listHeight int) {
+	var b strings.Builder
+
+	b.WriteString("\x1b[H\x1b[2J")
+	fmt.Fprintf(&b, "\x1b[1m>\x1b[0m %s\r\n", query)
+	fmt.Fprintf(&b, "\x1b[2m  %d/%d  (● = run in this directory)\x1b[0m", len(matches), total)
+
+	now := time.Now()
+	for i := offset; i < len(matches) && i < offset+listHeight; i++ {
+		c := matches[i]
+
+		marker := " "
+		if c.InPwd {
+			marker = "●"
+		}
+		status := "\x1b[32m✓\x1b[0m   "
+		switch c.ExitCode {
+		case "0":
+		case "":
+			status = "?   "
+		default:
+			status = fmt.Sprintf("\x1b[31m✗%-3s\x1b[0m", c.ExitCode)
+		}
+
+		// marker, status, age and spacing take 14 columns
+		line := truncateRunes(strings.ReplaceAll(c.Command, "\n", "↵"), width-14)
+
+		b.WriteString("\r\n")
+		if i == cursor {
+			// Re-enable reverse video after the colored status resets it
+			b.WriteString("\x1b[7m")
+			status += "\x1b[7m"
+		}
+		fmt.Fprintf(&b, "%s %s %5s  %s", marker, status, formatAge(now.Sub(c.LastRun)), line)
+		if i == cursor {
+			b.WriteString("\x1b[K\x1b[0m")
+		}
+	}
+
+	// Park the cursor at the end of the query
+	fmt.Fprintf(&b, "\x1b[1;%dH", utf8.RuneCountInString(query)+3)
+	fmt.Fprint(out, b.String())
+}
+
+func truncateRunes(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	if n == 1 {
+		return "…"
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// formatAge renders a duration in the compact style of `ls -l`-like tools.
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case d < 7*24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d < 365*24*time.Hour:
+		return fmt.Sprintf("%dw", int(d.Hours()/(24*7)))
+	default:
+		return fmt.Sprintf("%dy", int(d.Hours()/(24*365)))
+	}
+}
+
+func init() {
+	pickCmd.Flags().String("pwd", "", "Directory whose commands are listed first (default: current directory)")
+	pickCmd.Flags().String("query", "", "Initial filter text")
+	pickCmd.Flags().Int("limit", 5000, "Number of recent history entries to search")
+	rootCmd.AddCommand(pickCmd)
+}
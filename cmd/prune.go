@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tanu2534/cmdo/config"
+	"github.com/tanu2534/cmdo/database"
+)
+
+// retentionInterval is how often 'cmdo log' applies the retention settings.
+const retentionInterval = 24 * time.Hour
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete old commands from history",
+	Long: `prune deletes commands older than --older-than or beyond the newest --keep-last; with both, a command goes if either limit says so.
+--dir and --exit-code restrict pruning to matching commands (and --keep-last then counts only those). Use --dry-run to see how many commands would go.
+Given only --dir or --exit-code, prune deletes every matching command and asks for --yes to confirm that.
+
+Without any flags prune applies the retention.max_age and retention.keep_last settings, which 'cmdo log' also applies once a day.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		olderThan, _ := cmd.Flags().GetString("older-than")
+		keepLast, _ := cmd.Flags().GetInt("keep-last")
+		dir, _ := cmd.Flags().GetString("dir")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		yes, _ := cmd.Flags().GetBool("yes")
+
+		cfg := config.Get().Retention
+		if !cmd.Flags().Changed("older-than") && !cmd.Flags().Changed("keep-last") &&
+			!cmd.Flags().Changed("dir") && !cmd.Flags().Changed("exit-code") {
+			if cfg.MaxAge == "" && cfg.KeepLast <= 0 {
+				fmt.Println("❌ Nothing to prune: pass --older-than, --keep-last, --dir or --exit-code, or set retention.max_age / retention.keep_last")
+				os.Exit(1)
+			}
+			olderThan, keepLast = cfg.MaxAge, cfg.KeepLast
+		}
+
+		opts, err := pruneOptions(olderThan, keepLast, time.Now())
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+		if dir != "" {
+			absDir, err := filepath.Abs(dir)
+			if err != nil {
+				fmt.Println("❌", err)
+				os.Exit(1)
+			}
+			opts.Filter.Dir = absDir
+		}
+		if cmd.Flags().Changed("exit-code") {
+			exitCode, _ := cmd.Flags().GetInt("exit-code")
+			opts.Filter.ExitCode = &exitCode
+		}
+		if opts.OlderThan.IsZero() && opts.KeepLast <= 0 && !dryRun && !yes {
+			fmt.Println("❌ Without --older-than or --keep-last this deletes every matching command; add --yes to confirm or --dry-run to count them")
+			os.Exit(1)
+		}
+
+		database.InitDB(database.GetGlobalDBPath())
+		defer database.DB.Close()
+
+		removed, err := database.PruneCommands(opts, dryRun)
+		if err != nil {
+			fmt.Println("❌ Error pruning commands:", err)
+			os.Exit(1)
+		}
+
+		if dryRun {
+			fmt.Printf("%d command(s) would be deleted. Run without --dry-run to delete them.\n", removed)
+			return
+		}
+		fmt.Printf("🧹 Deleted %d command(s)\n", removed)
+
+		if removed > 0 && removed >= cfg.VacuumThreshold {
+			if err := database.Vacuum(); err != nil {
+				fmt.Println("❌ Error compacting database:", err)
+				os.Exit(1)
+			}
+			fmt.Println("✨ Database compacted")
+		}
+	},
+}
+
+func pruneOptions(olderThan string, keepLast int, now time.Time) (database.PruneOptions, error) {
+	opts := database.PruneOptions{KeepLast: keepLast}
+	if olderThan != "" {
+		age, err := parseAge(olderThan)
+		if err != nil {
+			return opts, err
+		}
+		opts.OlderThan = now.Add(-age)
+	}
+	if keepLast < 0 {
+		return opts, fmt.Errorf("--keep-last must not be negative")
+	}
+	return opts, nil
+}
+
+// applyRetention enforces the retention settings on behalf of 'cmdo log'.
+// It runs at most once per retentionInterval, tracked by the modification
+// time of a marker file next to the database, so most logs don't pay for it.
+func applyRetention() error {
+	cfg := config.Get().Retention
+	if cfg.MaxAge == "" && cfg.KeepLast <= 0 {
+		return nil
+	}
+
+	marker := filepath.Join(filepath.Dir(database.GetGlobalDBPath()), "last_prune")
+	if info, err := os.Stat(marker); err == nil && time.Since(info.ModTime()) < retentionInterval {
+		return nil
+	}
+	// Touch the marker first so concurrent shells don't all prune at once
+	if err := os.WriteFile(marker, nil, 0644); err != nil {
+		return err
+	}
+
+	opts, err := pruneOptions(cfg.MaxAge, cfg.KeepLast, time.Now())
+	if err != nil {
+		return fmt.Errorf("invalid retention settings: %w", err)
+	}
+
+	removed, err := database.PruneCommands(opts, false)
+	if err != nil {
+		return err
+	}
+	if removed > 0 && removed >= cfg.VacuumThreshold {
+		return database.Vacuum()
+	}
+	return nil
+}
+
+func init() {
+	pruneCmd.Flags().String("older-than", "", "Delete commands older than this age (e.g. 90d, 12w, 720h)")
+	pruneCmd.Flags().Int("keep-last", 0, "Keep only the newest N commands")
+	pruneCmd.Flags().String("dir", "", "Only prune commands run in this directory or below it")
+	pruneCmd.Flags().Int("exit-code", 0, "Only prune commands that exited with this code")
+	pruneCmd.Flags().Bool("dry-run", false, "Show how many commands would be deleted without deleting them")
+	pruneCmd.Flags().Bool("yes", false, "Delete every command matching --dir or --exit-code when no limit is given")
+	rootCmd.AddCommand(pruneCmd)
+}
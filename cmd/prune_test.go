@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPruneOptions(t *testing.T) {
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		olderThan     string
+		keepLast      int
+		wantOlderThan time.Time
+		wantErr       bool
+	}{
+		{"no limits", "", 0, time.Time{}, false},
+		{"days", "90d", 0, now.Add(-90 * 24 * time.Hour), false},
+		{"weeks", "2w", 0, now.Add(-14 * 24 * time.Hour), false},
+		{"fractional days", "1.5d", 0, now.Add(-36 * time.Hour), false},
+		{"go duration", "720h", 0, now.Add(-720 * time.Hour), false},
+		{"keep last", "", 100, time.Time{}, false},
+		{"negative age", "-1d", 0, time.Time{}, true},
+		{"unknown unit", "3y", 0, time.Time{}, true},
+		{"negative keep last", "", -1, time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := pruneOptions(tt.olderThan, tt.keepLast, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !opts.OlderThan.Equal(tt.wantOlderThan) {
+				t.Errorf("OlderThan = %v, want %v", opts.OlderThan, tt.wantOlderThan)
+			}
+			if opts.KeepLast != tt.keepLast {
+				t.Errorf("KeepLast = %d, want %d", opts.KeepLast, tt.keepLast)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tanu2534/cmdo/config"
+	"github.com/tanu2534/cmdo/database"
+	"github.com/tanu2534/cmdo/redact"
+)
+
+var redactCmd = &cobra.Command{
+	Use:   "redact [command]",
+	Short: "Scrub secrets from command history",
+	Long: `redact replaces secrets such as tokens, passwords and credentials in URLs with placeholders.
+New commands are redacted by 'cmdo log' before they are stored; use --retroactive to scrub the rows already in the database.
+Given a command line instead, redact prints it the way it would be stored, which helps when testing custom patterns.
+
+Custom patterns are Go regular expressions in the redact.patterns config key, e.g.
+  cmdo config set redact.patterns '["corp-[0-9]{6}", "--pin (?P<secret>\\d+)"]'
+If a pattern has a group named "secret" only that group is replaced. Set redact.high_entropy to false to stop
+redacting long random-looking strings.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		retroactive, _ := cmd.Flags().GetBool("retroactive")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		redactor, err := loadRedactor()
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+
+		if len(args) == 1 {
+			fmt.Println(redactor.Redact(args[0]))
+			return
+		}
+
+		if !retroactive {
+			cmd.Help()
+			return
+		}
+
+		dbPath := database.GetGlobalDBPath()
+		if err := database.OpenDB(dbPath); err != nil {
+			fmt.Println("❌ Error opening database:", err)
+			os.Exit(1)
+		}
+		defer database.DB.Close()
+		if _, err := database.Migrate(); err != nil {
+			fmt.Println("❌ Error migrating database:", err)
+			os.Exit(1)
+		}
+
+		changed, err := database.RewriteCommands(redactor.Redact, !dryRun)
+		if err != nil {
+			fmt.Println("❌ Error redacting commands:", err)
+			os.Exit(1)
+		}
+
+		for _, c := range changed {
+			fmt.Printf("   %d: %s\n", c.ID, c.After)
+		}
+
+		switch {
+		case len(changed) == 0:
+			fmt.Println("✨ No secrets found in", dbPath)
+		case dryRun:
+			fmt.Printf("\n%d command(s) would be redacted. Run without --dry-run to apply.\n", len(changed))
+		default:
+			fmt.Printf("\n🔒 Redacted %d command(s)\n", len(changed))
+		}
+	},
+}
+
+// loadRedactor builds a redactor from the built-in rules and the redaction
+// settings in the config.
+func loadRedactor() (*redact.Redactor, error) {
+	cfg := config.Get().Redact
+
+	redactor, err := redact.New(cfg.Patterns)
+	if err != nil {
+		return nil, err
+	}
+	redactor.HighEntropy = cfg.HighEntropy
+	return redactor, nil
+}
+
+func init() {
+	redactCmd.Flags().Bool("retroactive", false, "Redact commands already stored in the database")
+	redactCmd.Flags().Bool("dry-run", false, "With --retroactive, show what would change without writing")
+	rootCmd.AddCommand(redactCmd)
+}
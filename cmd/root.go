@@ -5,17 +5,49 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/tanu2534/cmdo/config"
 )
 
+// configErr is why the config couldn't be loaded, kept for `cmdo log` to
+// report in its own quiet way.
+var configErr error
+
 var rootCmd = &cobra.Command{
 	Use:   "cmdo",
 	Short: "cmdo is a command logger tool",
 	Long:  "cmdo is a command logger tool.",
+	// Settings come from the config file, then CMDO_* variables, then flags
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if err := config.Init(); err != nil {
+			switch {
+			case cmd == logCmd:
+				// The shell hooks must stay quiet, so log refuses to store
+				// anything and only reports the error with --verbose
+				configErr = err
+			case cmd == doctorCmd:
+				// doctor reports the error as its Config check
+			default:
+				fmt.Println("❌ Error loading config:", err)
+				// Let the user fix the file with 'cmdo config'
+				if cmd.Parent() == nil || cmd.Parent().Name() != "config" {
+					os.Exit(1)
+				}
+			}
+		}
+
+		if dbPath, _ := cmd.Flags().GetString("db"); dbPath != "" {
+			config.Get().DBPath = dbPath
+		}
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 
 	},
 }
 
+func init() {
+	rootCmd.PersistentFlags().String("db", "", "Path of the history database (overrides db_path in the config)")
+}
+
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Oops. An error while executing CMDO: '%s'\n", err)
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tanu2534/cmdo/database"
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search command history",
+	Long: `search finds commands in the history database, best match first.
+
+Words are matched against the SQLite FTS5 index of the history (the last word may be
+a prefix) and results are ranked by relevance, then recency.
+
+--since and --until accept an age (90d, 2w, 12h, 30m) or a date
+(2006-01-02, "2006-01-02 15:04:05", RFC 3339).`,
+	Args: cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		opts, err := searchOptionsFromFlags(cmd)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		opts.Query = strings.Join(args, " ")
+
+		database.InitDB(database.GetGlobalDBPath())
+		defer database.DB.Close()
+
+		commands, err := database.SearchCommands(opts)
+		if err != nil {
+			fmt.Println("Error searching commands:", err)
+			os.Exit(1)
+		}
+
+		if len(commands) == 0 {
+			fmt.Println("No matching commands found")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TIME\tEXIT\tDIRECTORY\tCOMMAND")
+		for _, c := range commands {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Timestamp, exitCodeText(c.ExitCode), c.Directory, c.Command)
+		}
+		w.Flush()
+	},
+}
+
+// exitCodeText shows an unknown exit code, as imported history has, as "?".
+func exitCodeText(code string) string {
+	if code == "" {
+		return "?"
+	}
+	return code
+}
+
+// addSearchFilterFlags registers the filter flags shared by every command
+// that selects from history.
+func addSearchFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().String("dir", "", "Only commands run in this directory or below it")
+	cmd.Flags().Bool("failed", false, "Only commands with a non-zero exit code")
+	cmd.Flags().String("since", "", "Only commands logged at or after this time (e.g. 7d, 2024-01-31)")
+	cmd.Flags().String("until", "", "Only commands logged at or before this time (e.g. 1d, 2024-01-31)")
+}
+
+func searchOptionsFromFlags(cmd *cobra.Command) (database.SearchOptions, error) {
+	var opts database.SearchOptions
+	now := time.Now()
+
+	dir, _ := cmd.Flags().GetString("dir")
+	if dir != "" {
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			return opts, err
+		}
+		opts.Dir = absDir
+	}
+
+	opts.FailedOnly, _ = cmd.Flags().GetBool("failed")
+
+	since, _ := cmd.Flags().GetString("since")
+	if since != "" {
+		t, err := parseTimeFlag(since, now)
+		if err != nil {
+			return opts, fmt.Errorf("invalid --since: %w", err)
+		}
+		opts.Since = t
+	}
+
+	until, _ := cmd.Flags().GetString("until")
+	if until != "" {
+		t, err := parseTimeFlag(until, now)
+		if err != nil {
+			return opts, fmt.Errorf("invalid --until: %w", err)
+		}
+		opts.Until = t
+	}
+
+	if cmd.Flags().Lookup("limit") != nil {
+		opts.Limit, _ = cmd.Flags().GetInt("limit")
+	}
+
+	return opts, nil
+}
+
+// parseTimeFlag reads either an age relative to now ("90d", "2w", "12h") or
+// an absolute local date/time.
+func parseTimeFlag(value string, now time.Time) (time.Time, error) {
+	if age, err := parseAge(value); err == nil {
+		return now.Add(-age), nil
+	}
+
+	for _, layout := range []string{database.TimestampFormat, "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("%q is neither an age (e.g. 7d) nor a date (e.g. 2006-01-02)", value)
+}
+
+// parseAge extends time.ParseDuration with day (d) and week (w) units.
+func parseAge(value string) (time.Duration, error) {
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	for suffix, unit := range units {
+		if n, ok := strings.CutSuffix(value, suffix); ok {
+			count, err := strconv.ParseFloat(n, 64)
+			if err != nil || count < 0 {
+				return 0, fmt.Errorf("invalid age %q", value)
+			}
+			return time.Duration(count * float64(unit)), nil
+		}
+	}
+
+	age, err := time.ParseDuration(value)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age %q", value)
+	}
+	return age, nil
+}
+
+func init() {
+	addSearchFilterFlags(searchCmd)
+	searchCmd.Flags().Int("limit", 20, "Maximum number of results")
+	rootCmd.AddCommand(searchCmd)
+}
@@ -1,17 +1,22 @@
 package cmd
 
 import (
+	"context"
 	"embed"
 	"encoding/json"
 	"fmt"
 	"html/template"
 	"log"
+	"net"
 	"net/http"
 	"os"
-	"path/filepath"
+	"os/signal"
+	"strconv"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/tanu2534/cmdo/config"
 	"github.com/tanu2534/cmdo/database"
 )
 
@@ -20,26 +25,161 @@ var indexHTML embed.FS
 
 // Command struct for JSON response
 type CommandJSON struct {
-	ID        string    `json:"id"`
-	Command   string    `json:"command"`
-	ExitCode  int       `json:"exitCode"`
+	ID      string `json:"id"`
+	Command string `json:"command"`
+	// ExitCode is nil when unknown, as for imported history
+	ExitCode  *int      `json:"exitCode"`
 	Timestamp time.Time `json:"timestamp"`
 	Folder    string    `json:"folder"`
+	// DurationMs is nil for commands logged before durations were recorded
+	DurationMs *int64 `json:"durationMs"`
+	SessionID  string `json:"sessionId,omitempty"`
+	// Source is empty for commands logged by the shell hooks
+	Source string `json:"source,omitempty"`
+}
+
+// CommandCreateJSON is the body of POST /api/commands
+type CommandCreateJSON struct {
+	Command  string `json:"command"`
+	ExitCode int    `json:"exitCode"`
+	Folder   string `json:"folder"`
+	// Timestamp is when the command finished; it defaults to now
+	Timestamp  *time.Time `json:"timestamp"`
+	DurationMs *int64     `json:"durationMs"`
+	SessionID  string     `json:"sessionId"`
+	// Source names the tool logging the command; it defaults to "api"
+	Source string `json:"source"`
+}
+
+// CommandPatchJSON is the body of PATCH /api/commands/{id}; omitted fields
+// are left unchanged.
+type CommandPatchJSON struct {
+	Command  *string `json:"command"`
+	ExitCode *int    `json:"exitCode"`
+	Folder   *string `json:"folder"`
+}
+
+// BulkDeleteJSON is the body of POST /api/commands/bulk-delete. Exactly one
+// of IDs and Filter must be given.
+type BulkDeleteJSON struct {
+	IDs    []commandID        `json:"ids"`
+	Filter *CommandFilterJSON `json:"filter"`
+	DryRun bool               `json:"dryRun"`
+}
+
+// CommandFilterJSON takes the same values as the /api/commands parameters.
+type CommandFilterJSON struct {
+	Q        string `json:"q"`
+	Folder   string `json:"folder"`
+	Session  string `json:"session"`
+	ExitCode *int   `json:"exitCode"`
+	Since    string `json:"since"`
+	Until    string `json:"until"`
+}
+
+// BulkDeleteResultJSON is the response of POST /api/commands/bulk-delete
+type BulkDeleteResultJSON struct {
+	Deleted int  `json:"deleted"`
+	DryRun  bool `json:"dryRun"`
+}
+
+// APIError is the body of every API error response
+type APIError struct {
+	Error string `json:"error"`
+}
+
+// SessionJSON is one entry of /api/sessions
+type SessionJSON struct {
+	ID           string `json:"id"`
+	Hostname     string `json:"hostname"`
+	User         string `json:"user"`
+	TTY          string `json:"tty"`
+	Shell        string `json:"shell"`
+	StartedAt    string `json:"startedAt"`
+	LastActivity string `json:"lastActivity"`
+	CommandCount int    `json:"commandCount"`
 }
 
 var serveCmd = &cobra.Command{
 	Use:   "serve",
 	Short: "Start the web UI server",
-	Long:  "Starts a local web server to view command history",
+	Long: `Starts a local web server to view command history.
+
+The server listens on 127.0.0.1 unless --bind (or server.bind in the config) says otherwise.
+Every launch generates a new access token: open the printed URL, which contains it, or send it
+as "Authorization: Bearer <token>" from scripts. Set server.password (or CMDO_SERVER_PASSWORD)
+to also allow logging in with a password, e.g. from another machine.
+
+Use --tls-cert and --tls-key to serve HTTPS with your own certificate, or --tls-self-signed to
+generate one under ~/.cmdo/ (reused until it is about to expire). The certificate's SHA-256
+fingerprint is printed so you can check it when your browser asks whether to trust it.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		port, _ := cmd.Flags().GetString("port")
+		cfg := config.Get()
+		port := strconv.Itoa(cfg.Server.Port)
+		if cmd.Flags().Changed("port") {
+			port, _ = cmd.Flags().GetString("port")
+		}
+		bind := cfg.Server.Bind
+		if cmd.Flags().Changed("bind") {
+			bind, _ = cmd.Flags().GetString("bind")
+		}
+		dbPath := database.GetGlobalDBPath()
 
-		// Use global DB path
-		homeDir, err := os.UserHomeDir()
+		certFile, keyFile, selfSigned := cfg.Server.TLSCert, cfg.Server.TLSKey, cfg.Server.TLSSelfSigned
+		if cmd.Flags().Changed("tls-cert") || cmd.Flags().Changed("tls-key") {
+			certFile, _ = cmd.Flags().GetString("tls-cert")
+			keyFile, _ = cmd.Flags().GetString("tls-key")
+			selfSigned = false
+		}
+		if cmd.Flags().Changed("tls-self-signed") {
+			selfSigned, _ = cmd.Flags().GetBool("tls-self-signed")
+		}
+		if (certFile == "") != (keyFile == "") {
+			fmt.Println("Error: --tls-cert and --tls-key must be used together")
+			os.Exit(1)
+		}
+		if selfSigned && certFile != "" {
+			fmt.Println("Error: --tls-self-signed can't be combined with --tls-cert/--tls-key")
+			os.Exit(1)
+		}
+
+		auth, err := newServerAuth(cfg.Server.Password, port)
 		if err != nil {
-			log.Fatal("Error getting home directory:", err)
+			fmt.Println("Error generating access token:", err)
+			os.Exit(1)
+		}
+
+		host := bind
+		if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+			host = "localhost"
+			fmt.Println("⚠️  Listening on all interfaces, anyone on your network who gets the URL can see your history")
+		}
+
+		scheme := "http"
+		var fingerprint string
+		if selfSigned {
+			certFile, keyFile, err = selfSignedPaths()
+			if err == nil {
+				var created bool
+				if created, err = ensureSelfSignedCert(certFile, keyFile, host); created {
+					fmt.Println("🔐 Generated a self-signed certificate:", certFile)
+				}
+			}
+			if err != nil {
+				fmt.Println("Error creating self-signed certificate:", err)
+				os.Exit(1)
+			}
+		}
+		if certFile != "" {
+			cert, err := loadCertificate(certFile, keyFile)
+			if err != nil {
+				fmt.Println("Error loading TLS certificate:", err)
+				os.Exit(1)
+			}
+			scheme = "https"
+			fingerprint = certFingerprint(cert)
+			auth.secure = true
 		}
-		dbPath := filepath.Join(homeDir, ".cmdo", "cmdo.db")
 
 		// Check if DB exists
 		if _, err := os.Stat(dbPath); os.IsNotExist(err) {
@@ -50,126 +190,531 @@ var serveCmd = &cobra.Command{
 		database.InitDB(dbPath)
 		defer database.DB.Close()
 
-		// API endpoints
-		http.HandleFunc("/api/commands", apiCommandsHandler)
-		http.HandleFunc("/api/delete", apiDeleteHandler)
-		http.HandleFunc("/api/clear", apiClearHandler)
-
-		// Serve HTML page
-		http.HandleFunc("/", indexHandler)
+		// Closed on shutdown to end /api/stream connections
+		streamsDone := make(chan struct{})
+		server := &http.Server{
+			Addr:              net.JoinHostPort(bind, port),
+			Handler:           logRequests(newServeMux(auth, streamsDone)),
+			ReadHeaderTimeout: 10 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			WriteTimeout:      time.Minute,
+			IdleTimeout:       2 * time.Minute,
+		}
+		server.RegisterOnShutdown(func() { close(streamsDone) })
 
-		fmt.Printf("CMDO Server running at http://localhost:%s\n", port)
+		baseURL := fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(host, port))
+		fmt.Printf("CMDO Server running at %s/?token=%s\n", baseURL, auth.token)
+		if auth.password != "" {
+			fmt.Printf("Or log in with server.password at %s/login\n", baseURL)
+		}
+		if fingerprint != "" {
+			fmt.Printf("🔒 Certificate SHA-256 fingerprint: %s\n", fingerprint)
+		}
 		fmt.Printf("Using database: %s\n", dbPath)
 		fmt.Println("Press Ctrl+C to stop")
-		log.Fatal(http.ListenAndServe(":"+port, nil))
+
+		if err := runServer(server, certFile, keyFile); err != nil {
+			fmt.Println("Error:", err)
+			database.DB.Close()
+			os.Exit(1)
+		}
+		fmt.Println("👋 Server stopped")
 	},
 }
 
-func indexHandler(w http.ResponseWriter, r *http.Request) {
-	// Read embedded file
-	content, err := indexHTML.ReadFile("server/index.html")
-	if err != nil {
-		http.Error(w, "Template not found", http.StatusInternalServerError)
-		log.Println("Error reading template:", err)
-		return
+// shutdownTimeout is how long in-flight requests get to finish on Ctrl+C
+const shutdownTimeout = 10 * time.Second
+
+// newServeMux routes the UI and API behind auth; only /healthz is public.
+func newServeMux(auth *serverAuth, streamsDone <-chan struct{}) *http.ServeMux {
+	app := http.NewServeMux()
+	app.HandleFunc("GET /api/commands", apiCommandsHandler)
+	app.HandleFunc("POST /api/commands", apiCreateCommandHandler)
+	app.HandleFunc("POST /api/commands/bulk-delete", apiBulkDeleteHandler)
+	app.HandleFunc("GET /api/commands/{id}", apiGetCommandHandler)
+	app.HandleFunc("PATCH /api/commands/{id}", apiUpdateCommandHandler)
+	app.HandleFunc("DELETE /api/commands/{id}", apiDeleteCommandHandler)
+	app.HandleFunc("GET /api/stream", streamHandler(streamsDone))
+	app.HandleFunc("GET /api/sessions", apiSessionsHandler)
+	app.HandleFunc("GET /api/stats", apiStatsHandler)
+	app.HandleFunc("GET /api/openapi.json", openAPIHandler)
+	// Kept for older clients; see DELETE /api/commands/{id}
+	app.HandleFunc("POST /api/delete", apiDeleteHandler)
+	app.HandleFunc("POST /api/clear", apiClearHandler)
+	app.Handle("/api/", apiFallbackHandler(app))
+	app.Handle("/", indexHandler(auth.csrfToken))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", healthzHandler)
+	mux.Handle("/", auth.middleware(app))
+	return mux
+}
+
+// runServer serves until SIGINT or SIGTERM, then stops accepting connections
+// and waits for in-flight requests. A second signal stops it immediately.
+func runServer(server *http.Server, certFile, keyFile string) error {
+	errs := make(chan error, 1)
+	go func() {
+		if certFile != "" {
+			errs <- server.ListenAndServeTLS(certFile, keyFile)
+		} else {
+			errs <- server.ListenAndServe()
+		}
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	select {
+	case err := <-errs:
+		return err
+	case sig := <-signals:
+		log.Printf("Received %s, shutting down (press Ctrl+C again to force)", sig)
 	}
 
-	// Parse template from embedded content
-	tmpl, err := template.New("index").Parse(string(content))
-	if err != nil {
-		http.Error(w, "Template error", http.StatusInternalServerError)
-		log.Println("Error parsing template:", err)
+	go func() {
+		<-signals
+		server.Close()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		server.Close()
+		return fmt.Errorf("requests still running after %s were cut off", shutdownTimeout)
+	}
+	return nil
+}
+
+// statusRecorder remembers what a handler wrote for logRequests.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// logRequests logs one line per request. Only the path is logged, so access
+// tokens in the query string stay out of the log.
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		log.Printf("%s %s %d %dB %s %s", r.Method, r.URL.Path, rec.status, rec.bytes,
+			time.Since(start).Round(time.Millisecond), r.RemoteAddr)
+	})
+}
+
+func healthzHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+	if err := database.DB.PingContext(ctx); err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"status": "unavailable", "error": err.Error()})
 		return
 	}
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// indexHandler serves the UI page with the CSRF token its POST requests send.
+func indexHandler(csrfToken string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Read embedded file
+		content, err := indexHTML.ReadFile("server/index.html")
+		if err != nil {
+			http.Error(w, "Template not found", http.StatusInternalServerError)
+			log.Println("Error reading template:", err)
+			return
+		}
+
+		// Parse template from embedded content
+		tmpl, err := template.New("index").Parse(string(content))
+		if err != nil {
+			http.Error(w, "Template error", http.StatusInternalServerError)
+			log.Println("Error parsing template:", err)
+			return
+		}
+
+		tmpl.Execute(w, struct{ CSRFToken string }{csrfToken})
+	}
+}
 
-	tmpl.Execute(w, nil)
+// CommandsPage is the response envelope of /api/commands
+type CommandsPage struct {
+	Commands   []CommandJSON `json:"commands"`
+	NextCursor *string       `json:"nextCursor"`
+	Total      int           `json:"total"`
 }
 
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
 func apiCommandsHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
-	log.Printf("apiCommandsHandler: Received request from %s", r.RemoteAddr)
+	opts, after, err := commandsQueryFromRequest(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	rows, err := database.DB.Query(`
-		SELECT id, command, exit_code, timestamp, directory 
-		FROM commands 
-		ORDER BY timestamp DESC
-	`)
+	commands, total, next, err := database.ListCommands(opts, after)
 	if err != nil {
 		log.Printf("apiCommandsHandler: Error querying database: %s", err)
-		http.Error(w, err.Error(), 500)
+		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	defer rows.Close()
 
-	var commands []CommandJSON
-	for rows.Next() {
-		var cmd CommandJSON
-		var ts string
-
-		err := rows.Scan(&cmd.ID, &cmd.Command, &cmd.ExitCode, &ts, &cmd.Folder)
+	page := CommandsPage{
+		Commands: make([]CommandJSON, 0, len(commands)),
+		Total:    total,
+	}
+	for _, c := range commands {
+		cmd, err := commandToJSON(c)
 		if err != nil {
-			log.Printf("apiCommandsHandler: Error scanning row: %s", err)
+			log.Printf("apiCommandsHandler: Error parsing time: %s", err)
 			continue
 		}
+		page.Commands = append(page.Commands, cmd)
+	}
+	if next != nil {
+		cursor := next.Encode()
+		page.NextCursor = &cursor
+	}
+
+	log.Printf("apiCommandsHandler: Returning %d of %d commands", len(page.Commands), total)
+	json.NewEncoder(w).Encode(page)
+}
+
+// commandsQueryFromRequest reads the filter and pagination parameters of
+// /api/commands: q, folder, exitCode, since, until, limit and cursor.
+func commandsQueryFromRequest(r *http.Request) (database.SearchOptions, *database.PageCursor, error) {
+	query := r.URL.Query()
+	now := time.Now()
+
+	opts := database.SearchOptions{
+		Query:   query.Get("q"),
+		Folder:  query.Get("folder"),
+		Session: query.Get("session"),
+		Limit:   defaultPageSize,
+	}
+
+	if v := query.Get("exitCode"); v != "" {
+		code, err := strconv.Atoi(v)
+		if err != nil {
+			return opts, nil, fmt.Errorf("invalid exitCode: %q", v)
+		}
+		opts.ExitCode = &code
+	}
 
-		parsedTime, err := time.Parse("2006-01-02 15:04:05", ts)
+	if v := query.Get("since"); v != "" {
+		t, err := parseTimeFlag(v, now)
 		if err != nil {
-			log.Printf("apiCommandsHandler: Error parsing time: %s", err)
-			continue
+			return opts, nil, fmt.Errorf("invalid since: %w", err)
 		}
+		opts.Since = t
+	}
+
+	if v := query.Get("until"); v != "" {
+		t, err := parseTimeFlag(v, now)
+		if err != nil {
+			return opts, nil, fmt.Errorf("invalid until: %w", err)
+		}
+		opts.Until = t
+	}
 
-		cmd.Timestamp = parsedTime
-		commands = append(commands, cmd)
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return opts, nil, fmt.Errorf("invalid limit: %q", v)
+		}
+		opts.Limit = min(limit, maxPageSize)
+	}
+
+	var after *database.PageCursor
+	if v := query.Get("cursor"); v != "" {
+		cursor, err := database.DecodePageCursor(v)
+		if err != nil {
+			return opts, nil, err
+		}
+		after = &cursor
 	}
 
-	log.Printf("apiCommandsHandler: Returning %d commands", len(commands))
-	json.NewEncoder(w).Encode(commands)
+	return opts, after, nil
 }
 
-func apiDeleteHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", 405)
+func commandToJSON(c database.Command) (CommandJSON, error) {
+	// Timestamps are stored in local time
+	parsedTime, err := time.ParseInLocation(database.TimestampFormat, c.Timestamp, time.Local)
+	if err != nil {
+		return CommandJSON{}, err
+	}
+
+	cmd := CommandJSON{
+		ID:        strconv.Itoa(c.ID),
+		Command:   c.Command,
+		Timestamp: parsedTime,
+		Folder:    c.Directory,
+	}
+	if exitCode, err := strconv.Atoi(c.ExitCode); err == nil {
+		cmd.ExitCode = &exitCode
+	}
+	if c.DurationMs.Valid {
+		cmd.DurationMs = &c.DurationMs.Int64
+	}
+	cmd.SessionID = c.SessionID.String
+	cmd.Source = c.Source.String
+	return cmd, nil
+}
+
+const (
+	streamPollInterval = time.Second
+	// Comments sent this often keep proxies from closing idle streams
+	streamKeepAlive = 15 * time.Second
+	// Only commands logged this recently are streamed, so imported or
+	// back-dated history doesn't show up as if it had just run
+	streamMaxAge = 5 * time.Minute
+)
+
+// streamHandler serves /api/stream, a Server-Sent Events feed of newly
+// logged commands. It polls MAX(id) and sends each new command matching the
+// q, folder, exitCode and session parameters, and logged within the last
+// streamMaxAge, as a "command" event. Event ids
+// are command ids, so a reconnecting EventSource resumes after the last one
+// it saw through Last-Event-ID; "after" does the same for other clients.
+// Streams end when done is closed so they don't hold up shutdown.
+func streamHandler(done <-chan struct{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts, _, err := commandsQueryFromRequest(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		opts.Limit = maxPageSize
+		since := opts.Since
+
+		lastID := -1
+		for _, v := range []string{r.Header.Get("Last-Event-ID"), r.URL.Query().Get("after")} {
+			if v == "" || lastID >= 0 {
+				continue
+			}
+			if lastID, err = strconv.Atoi(v); err != nil || lastID < 0 {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid event id: %q", v))
+				return
+			}
+		}
+		maxID, err := database.MaxCommandID()
+		if err != nil {
+			log.Printf("streamHandler: Error querying database: %s", err)
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if lastID < 0 {
+			lastID = maxID
+		}
+
+		rc := http.NewResponseController(w)
+		// The server's WriteTimeout would otherwise cut the stream off
+		rc.SetWriteDeadline(time.Time{})
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		fmt.Fprint(w, "retry: 3000\n\n")
+
+		poll := time.NewTicker(streamPollInterval)
+		defer poll.Stop()
+		keepAlive := time.NewTicker(streamKeepAlive)
+		defer keepAlive.Stop()
+
+		for {
+			// Catch up in pages of opts.Limit; the filter may skip rows, so
+			// an empty page means everything up to maxID has been seen
+			opts.Since = time.Now().Add(-streamMaxAge)
+			if since.After(opts.Since) {
+				opts.Since = since
+			}
+			for lastID < maxID {
+				commands, err := database.CommandsAfter(opts, lastID)
+				if err != nil {
+					log.Printf("streamHandler: Error querying database: %s", err)
+					return
+				}
+				if len(commands) == 0 {
+					lastID = maxID
+					break
+				}
+				for _, c := range commands {
+					lastID = c.ID
+					cmd, err := commandToJSON(c)
+					if err != nil {
+						continue
+					}
+					data, _ := json.Marshal(cmd)
+					fmt.Fprintf(w, "id: %d\nevent: command\ndata: %s\n\n", c.ID, data)
+				}
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+
+			select {
+			case <-r.Context().Done():
+				return
+			case <-done:
+				return
+			case <-keepAlive.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+			case <-poll.C:
+				if maxID, err = database.MaxCommandID(); err != nil {
+					log.Printf("streamHandler: Error querying database: %s", err)
+					return
+				}
+				// Ids of deleted rows at the end of the table get reused
+				lastID = min(lastID, maxID)
+			}
+		}
+	}
+}
+
+func apiSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	limit := defaultPageSize
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid limit: %q", v))
+			return
+		}
+		limit = min(n, maxPageSize)
+	}
+
+	sessions, err := database.ListSessions(limit)
+	if err != nil {
+		log.Printf("apiSessionsHandler: Error querying database: %s", err)
+		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	result := make([]SessionJSON, 0, len(sessions))
+	for _, s := range sessions {
+		result = append(result, SessionJSON{
+			ID:           s.ID,
+			Hostname:     s.Hostname,
+			User:         s.User,
+			TTY:          s.TTY,
+			Shell:        s.Shell,
+			StartedAt:    s.StartedAt,
+			LastActivity: s.LastActivity,
+			CommandCount: s.CommandCount,
+		})
+	}
+
+	json.NewEncoder(w).Encode(result)
+}
+
+func apiStatsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+	var opts database.StatsOptions
+
+	if v := query.Get("since"); v != "" {
+		t, err := parseTimeFlag(v, time.Now())
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid since: %s", err))
+			return
+		}
+		opts.Since = t
+	}
+
+	for name, target := range map[string]*int{"top": &opts.Top, "days": &opts.Days} {
+		if v := query.Get(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 || n > 366 {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid %s: %q", name, v))
+				return
+			}
+			*target = n
+		}
+	}
+
+	stats, err := database.GetStats(opts)
+	if err != nil {
+		log.Printf("apiStatsHandler: Error computing stats: %s", err)
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(stats)
+}
+
+func apiDeleteHandler(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		ID string `json:"id"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request", 400)
+		writeError(w, http.StatusBadRequest, "Invalid request")
 		return
 	}
 
-	_, err := database.DB.Exec("DELETE FROM commands WHERE id = ?", req.ID)
+	id, err := strconv.Atoi(req.ID)
 	if err != nil {
-		http.Error(w, err.Error(), 500)
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid command id: %q", req.ID))
 		return
 	}
 
-	w.WriteHeader(200)
-	json.NewEncoder(w).Encode(map[string]bool{"success": true})
-}
-
-func apiClearHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", 405)
+	if _, err := database.DeleteCommands([]int{id}); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	_, err := database.DB.Exec("DELETE FROM commands")
-	if err != nil {
-		http.Error(w, err.Error(), 500)
+	writeJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+func apiClearHandler(w http.ResponseWriter, r *http.Request) {
+	if _, err := database.DeleteAllCommands(); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	w.WriteHeader(200)
-	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+	writeJSON(w, http.StatusOK, map[string]bool{"success": true})
 }
 
 func init() {
-	serveCmd.Flags().String("port", "8089", "Port to run the server on")
+	serveCmd.Flags().String("port", "8089", "Port to run the server on (overrides server.port in the config)")
+	serveCmd.Flags().String("bind", "127.0.0.1", "Address to listen on, 0.0.0.0 for all interfaces (overrides server.bind in the config)")
+	serveCmd.Flags().String("tls-cert", "", "PEM certificate to serve HTTPS with (needs --tls-key)")
+	serveCmd.Flags().String("tls-key", "", "PEM private key of --tls-cert")
+	serveCmd.Flags().Bool("tls-self-signed", false, "Serve HTTPS with a self-signed certificate generated under ~/.cmdo/")
 	rootCmd.AddCommand(serveCmd)
 }
@@ -0,0 +1,365 @@
+package cmd
+
+import (
+	"bytes"
+	"database/sql"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tanu2534/cmdo/database"
+)
+
+//go:embed server/openapi.json
+var openAPISpec []byte
+
+// maxRequestBody caps JSON request bodies
+const maxRequestBody = 1 << 20
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, APIError{Error: message})
+}
+
+// decodeJSON reads a request body into v, rejecting unknown fields so typos
+// don't silently do nothing.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("request body is empty")
+		}
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	if dec.More() {
+		return fmt.Errorf("invalid request body: more than one JSON value")
+	}
+	return nil
+}
+
+// commandID accepts ids both as JSON numbers and, like CommandJSON.ID, as
+// strings.
+type commandID int
+
+func (id *commandID) UnmarshalJSON(data []byte) error {
+	n, err := strconv.Atoi(string(bytes.Trim(data, `"`)))
+	if err != nil || n <= 0 {
+		return fmt.Errorf("invalid command id %s", data)
+	}
+	*id = commandID(n)
+	return nil
+}
+
+// pathCommandID reads the {id} of /api/commands/{id}, writing a 400 response
+// when it isn't a valid id.
+func pathCommandID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid command id: %q", r.PathValue("id")))
+		return 0, false
+	}
+	return id, true
+}
+
+// writeCommand responds with one command as CommandJSON.
+func writeCommand(w http.ResponseWriter, status int, c database.Command) {
+	cmd, err := commandToJSON(c)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, status, cmd)
+}
+
+func apiGetCommandHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathCommandID(w, r)
+	if !ok {
+		return
+	}
+
+	c, err := database.GetCommand(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("command %d not found", id))
+		return
+	}
+	if err != nil {
+		log.Printf("apiGetCommandHandler: Error querying database: %s", err)
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeCommand(w, http.StatusOK, c)
+}
+
+func apiUpdateCommandHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathCommandID(w, r)
+	if !ok {
+		return
+	}
+
+	var req CommandPatchJSON
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Command == nil && req.ExitCode == nil && req.Folder == nil {
+		writeError(w, http.StatusBadRequest, "nothing to update: give command, exitCode or folder")
+		return
+	}
+	if req.Command != nil && strings.TrimSpace(*req.Command) == "" {
+		writeError(w, http.StatusBadRequest, "command can't be empty")
+		return
+	}
+
+	// Edited commands are redacted just like new ones
+	if req.Command != nil {
+		redactor, err := loadRedactor()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		redacted := redactor.Redact(*req.Command)
+		req.Command = &redacted
+	}
+
+	c, err := database.UpdateCommand(id, database.CommandUpdate{
+		Command:   req.Command,
+		ExitCode:  req.ExitCode,
+		Directory: req.Folder,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("command %d not found", id))
+		return
+	}
+	if err != nil {
+		log.Printf("apiUpdateCommandHandler: Error updating command %d: %s", id, err)
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeCommand(w, http.StatusOK, c)
+}
+
+func apiDeleteCommandHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathCommandID(w, r)
+	if !ok {
+		return
+	}
+
+	removed, err := database.DeleteCommands([]int{id})
+	if err != nil {
+		log.Printf("apiDeleteCommandHandler: Error deleting command %d: %s", id, err)
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if removed == 0 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("command %d not found", id))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// apiCreateCommandHandler logs a command sent by another tool. Like
+// `cmdo log` it honours paused sessions, the ignore rules and redaction; a
+// command that is skipped gets a 200 with the logEvent saying why instead of
+// a 201 with the stored command.
+func apiCreateCommandHandler(w http.ResponseWriter, r *http.Request) {
+	var req CommandCreateJSON
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if strings.TrimSpace(req.Command) == "" || req.Folder == "" {
+		writeError(w, http.StatusBadRequest, "command and folder are required")
+		return
+	}
+	if req.DurationMs != nil && *req.DurationMs < 0 {
+		writeError(w, http.StatusBadRequest, "durationMs can't be negative")
+		return
+	}
+
+	if isLoggingPaused(req.SessionID) {
+		writeJSON(w, http.StatusOK, logEvent{Status: "paused"})
+		return
+	}
+	rules, err := loadIgnoreRules()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if reason, ignored := rules.Match(req.Command, req.Folder); ignored {
+		writeJSON(w, http.StatusOK, logEvent{Status: "ignored", Reason: reason})
+		return
+	}
+	redactor, err := loadRedactor()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	entry := database.LogEntry{
+		Command:    redactor.Redact(req.Command),
+		ExitCode:   strconv.Itoa(req.ExitCode),
+		Directory:  req.Folder,
+		DurationMs: -1,
+		LoggedAt:   time.Now(),
+		Source:     req.Source,
+	}
+	if entry.Source == "" {
+		entry.Source = "api"
+	}
+	if req.Timestamp != nil {
+		entry.LoggedAt = req.Timestamp.Local()
+	}
+	if req.DurationMs != nil {
+		entry.DurationMs = *req.DurationMs
+		entry.StartTime = entry.LoggedAt.Add(-time.Duration(*req.DurationMs) * time.Millisecond)
+	}
+	if req.SessionID != "" {
+		entry.Session = currentSession(req.SessionID, "", "")
+	}
+
+	id, err := database.InsertCommand(entry)
+	if err != nil {
+		log.Printf("apiCreateCommandHandler: Error inserting command: %s", err)
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c, err := database.GetCommand(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/commands/%d", id))
+	writeCommand(w, http.StatusCreated, c)
+}
+
+// apiBulkDeleteHandler deletes a list of commands or every command matching
+// a filter. The filter has to narrow things down; POST /api/clear is the way
+// to delete everything.
+func apiBulkDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	var req BulkDeleteJSON
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if (len(req.IDs) > 0) == (req.Filter != nil) {
+		writeError(w, http.StatusBadRequest, "give either ids or filter")
+		return
+	}
+
+	var removed int
+	var err error
+	if req.Filter != nil {
+		opts, ferr := req.Filter.searchOptions()
+		if ferr != nil {
+			writeError(w, http.StatusBadRequest, ferr.Error())
+			return
+		}
+		removed, err = database.PruneCommands(database.PruneOptions{Filter: opts}, req.DryRun)
+	} else {
+		ids := make([]int, len(req.IDs))
+		for i, id := range req.IDs {
+			ids[i] = int(id)
+		}
+		if req.DryRun {
+			removed, err = countCommands(ids)
+		} else {
+			removed, err = database.DeleteCommands(ids)
+		}
+	}
+	if err != nil {
+		log.Printf("apiBulkDeleteHandler: Error deleting commands: %s", err)
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, BulkDeleteResultJSON{Deleted: removed, DryRun: req.DryRun})
+}
+
+func (f CommandFilterJSON) searchOptions() (database.SearchOptions, error) {
+	opts := database.SearchOptions{
+		Query:    f.Q,
+		Folder:   f.Folder,
+		Session:  f.Session,
+		ExitCode: f.ExitCode,
+	}
+
+	now := time.Now()
+	if f.Since != "" {
+		t, err := parseTimeFlag(f.Since, now)
+		if err != nil {
+			return opts, fmt.Errorf("invalid since: %w", err)
+		}
+		opts.Since = t
+	}
+	if f.Until != "" {
+		t, err := parseTimeFlag(f.Until, now)
+		if err != nil {
+			return opts, fmt.Errorf("invalid until: %w", err)
+		}
+		opts.Until = t
+	}
+
+	if strings.TrimSpace(opts.Query) == "" && opts.Folder == "" && opts.Session == "" &&
+		opts.ExitCode == nil && opts.Since.IsZero() && opts.Until.IsZero() {
+		return opts, fmt.Errorf("filter matches every command; use POST /api/clear to delete everything")
+	}
+	return opts, nil
+}
+
+// countCommands reports how many of ids exist, for dry runs.
+func countCommands(ids []int) (int, error) {
+	seen := map[int]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if _, err := database.GetCommand(id); errors.Is(err, sql.ErrNoRows) {
+			delete(seen, id)
+		} else if err != nil {
+			return 0, err
+		}
+	}
+	return len(seen), nil
+}
+
+func openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
+
+// apiFallbackHandler answers requests no API route matched: 405 with an
+// Allow header when the path exists for other methods, 404 otherwise.
+func apiFallbackHandler(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var allowed []string
+		for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPatch, http.MethodDelete} {
+			probe := r.Clone(r.Context())
+			probe.Method = method
+			if _, pattern := mux.Handler(probe); pattern != "" && pattern != "/api/" {
+				allowed = append(allowed, method)
+			}
+		}
+
+		if len(allowed) > 0 {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("%s is not allowed on %s", r.Method, r.URL.Path))
+			return
+		}
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no API endpoint at %s", r.URL.Path))
+	})
+}
@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tanu2534/cmdo/config"
+	"github.com/tanu2534/cmdo/database"
+)
+
+// newTestServer serves the API from a fresh database holding three commands
+// (ids 1 to 3) and returns it with the token to authenticate with.
+func newTestServer(t *testing.T) (http.Handler, string) {
+	t.Helper()
+	dir := t.TempDir()
+
+	configPath := filepath.Join(dir, "config.toml")
+	err := os.WriteFile(configPath, []byte(`paused_sessions = ["paused-shell"]
+
+[ignore]
+patterns = ["secret-tool*"]
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CMDO_CONFIG", configPath)
+	if err := config.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := database.OpenDB(filepath.Join(dir, "cmdo.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.DB.Close() })
+	if _, err := database.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, command := range []string{"make build", "make test", "git status"} {
+		entry := database.LogEntry{Command: command, ExitCode: "0", Directory: "/work", DurationMs: -1}
+		if err := database.InsertCmd(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	auth, err := newServerAuth("", "0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	streamsDone := make(chan struct{})
+	t.Cleanup(func() { close(streamsDone) })
+	return newServeMux(auth, streamsDone), auth.token
+}
+
+func TestAPIStatusCodes(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		// wantBody is a substring of the response body
+		wantBody string
+	}{
+		{"get command", "GET", "/api/commands/1", "", http.StatusOK, `"command":"make build"`},
+		{"get missing command", "GET", "/api/commands/99", "", http.StatusNotFound, `"error"`},
+		{"get invalid id", "GET", "/api/commands/abc", "", http.StatusBadRequest, `"error"`},
+
+		{"update command", "PATCH", "/api/commands/1", `{"exitCode": 2}`, http.StatusOK, `"exitCode":2`},
+		{"update redacts", "PATCH", "/api/commands/1", `{"command": "export API_KEY=abc123"}`, http.StatusOK, "redacted:secret-variable"},
+		{"update nothing", "PATCH", "/api/commands/1", `{}`, http.StatusBadRequest, "nothing to update"},
+		{"update to empty command", "PATCH", "/api/commands/1", `{"command": " "}`, http.StatusBadRequest, "can't be empty"},
+		{"update unknown field", "PATCH", "/api/commands/1", `{"cmd": "ls"}`, http.StatusBadRequest, "unknown field"},
+		{"update missing command", "PATCH", "/api/commands/99", `{"exitCode": 1}`, http.StatusNotFound, `"error"`},
+
+		{"delete command", "DELETE", "/api/commands/2", "", http.StatusNoContent, ""},
+		{"delete missing command", "DELETE", "/api/commands/99", "", http.StatusNotFound, `"error"`},
+
+		{"create command", "POST", "/api/commands", `{"command": "ls", "folder": "/tmp", "durationMs": 5}`, http.StatusCreated, `"source":"api"`},
+		{"create redacts", "POST", "/api/commands", `{"command": "curl -u x https://me:pw@host", "folder": "/tmp"}`, http.StatusCreated, "redacted:url-credentials"},
+		{"create ignored", "POST", "/api/commands", `{"command": "secret-tool get", "folder": "/tmp"}`, http.StatusOK, `"status":"ignored"`},
+		{"create in paused session", "POST", "/api/commands", `{"command": "ls", "folder": "/tmp", "sessionId": "paused-shell"}`, http.StatusOK, `"status":"paused"`},
+		{"create without folder", "POST", "/api/commands", `{"command": "ls"}`, http.StatusBadRequest, "required"},
+		{"create with negative duration", "POST", "/api/commands", `{"command": "ls", "folder": "/tmp", "durationMs": -1}`, http.StatusBadRequest, "negative"},
+		{"create with empty body", "POST", "/api/commands", "", http.StatusBadRequest, "empty"},
+		{"create with two values", "POST", "/api/commands", `{"command": "ls", "folder": "/tmp"} {}`, http.StatusBadRequest, "more than one"},
+
+		{"bulk delete ids", "POST", "/api/commands/bulk-delete", `{"ids": [1, "2", 99]}`, http.StatusOK, `"deleted":2`},
+		{"bulk delete dry run", "POST", "/api/commands/bulk-delete", `{"ids": [1, 1, 3], "dryRun": true}`, http.StatusOK, `"deleted":2,"dryRun":true`},
+		{"bulk delete filter", "POST", "/api/commands/bulk-delete", `{"filter": {"q": "make"}}`, http.StatusOK, `"deleted":2`},
+		{"bulk delete everything", "POST", "/api/commands/bulk-delete", `{"filter": {}}`, http.StatusBadRequest, "/api/clear"},
+		{"bulk delete ids and filter", "POST", "/api/commands/bulk-delete", `{"ids": [1], "filter": {"q": "make"}}`, http.StatusBadRequest, "either"},
+		{"bulk delete nothing", "POST", "/api/commands/bulk-delete", `{}`, http.StatusBadRequest, "either"},
+		{"bulk delete invalid id", "POST", "/api/commands/bulk-delete", `{"ids": [0]}`, http.StatusBadRequest, "invalid command id"},
+		{"bulk delete invalid since", "POST", "/api/commands/bulk-delete", `{"filter": {"since": "someday"}}`, http.StatusBadRequest, "invalid since"},
+
+		{"legacy delete", "POST", "/api/delete", `{"id": "1"}`, http.StatusOK, `"success":true`},
+		{"legacy delete invalid id", "POST", "/api/delete", `{"id": "x"}`, http.StatusBadRequest, "invalid command id"},
+		{"legacy clear", "POST", "/api/clear", "", http.StatusOK, `"success":true`},
+
+		{"list commands", "GET", "/api/commands?limit=2", "", http.StatusOK, `"total":3`},
+		{"list with invalid limit", "GET", "/api/commands?limit=0", "", http.StatusBadRequest, "invalid limit"},
+		{"list with invalid cursor", "GET", "/api/commands?cursor=x", "", http.StatusBadRequest, "invalid cursor"},
+
+		{"method not allowed", "PUT", "/api/commands/1", "", http.StatusMethodNotAllowed, "not allowed"},
+		{"unknown endpoint", "GET", "/api/nothing", "", http.StatusNotFound, "no API endpoint"},
+		{"openapi document", "GET", "/api/openapi.json", "", http.StatusOK, `"openapi"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, token := newTestServer(t)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d; body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body %s doesn't contain %s", rec.Body, tt.wantBody)
+			}
+			if rec.Code >= 400 && rec.Header().Get("Content-Type") != "application/json" {
+				t.Errorf("error response has Content-Type %q", rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestAPIAuthentication(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"wrong token", "Bearer nope", http.StatusUnauthorized},
+		{"right token", "Bearer <token>", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, token := newTestServer(t)
+
+			req := httptest.NewRequest("GET", "/api/commands/1", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", strings.ReplaceAll(tt.header, "<token>", token))
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestAPICreateCommandLocation(t *testing.T) {
+	handler, token := newTestServer(t)
+
+	req := httptest.NewRequest("POST", "/api/commands", strings.NewReader(`{"command": "ls", "folder": "/tmp", "exitCode": 1}`))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var created CommandJSON
+	if err := json.NewDecoder(rec.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	if want := "/api/commands/" + created.ID; rec.Header().Get("Location") != want {
+		t.Errorf("Location = %q, want %q", rec.Header().Get("Location"), want)
+	}
+
+	c, err := database.GetCommand(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Command != "ls" || c.ExitCode != "1" || c.Source.String != "api" {
+		t.Errorf("stored %+v", c)
+	}
+}
+
+func TestStreamSkipsOldCommands(t *testing.T) {
+	handler, token := newTestServer(t)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	old := database.LogEntry{Command: "imported", ExitCode: "0", Directory: "/work", DurationMs: -1,
+		LoggedAt: time.Now().AddDate(-2, 0, 0), Source: "import:bash"}
+	fresh := database.LogEntry{Command: "just ran", ExitCode: "0", Directory: "/work", DurationMs: -1}
+	for _, entry := range []database.LogEntry{old, fresh} {
+		if err := database.InsertCmd(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/api/stream?after=3", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+			if !strings.Contains(data, `"command":"just ran"`) {
+				t.Errorf("first streamed command is %s, want the fresh one", data)
+			}
+			return
+		}
+	}
+	t.Fatalf("stream ended without a command: %v", scanner.Err())
+}
+
+func TestCommandToJSONExitCode(t *testing.T) {
+	tests := []struct {
+		exitCode string
+		want     string
+	}{
+		{"0", `"exitCode":0`},
+		{"127", `"exitCode":127`},
+		{"", `"exitCode":null`},
+	}
+
+	for _, tt := range tests {
+		c := database.Command{ID: 1, Command: "ls", ExitCode: tt.exitCode, Timestamp: "2024-01-02 03:04:05"}
+		cmd, err := commandToJSON(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := json.Marshal(cmd)
+		if !strings.Contains(string(data), tt.want) {
+			t.Errorf("exit code %q encoded as %s, want %s", tt.exitCode, data, tt.want)
+		}
+	}
+}
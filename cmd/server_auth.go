@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// serverAuth guards the web UI. Every launch of `cmdo serve` generates a
+// fresh access token that is printed in the URL; opening that URL (or
+// logging in with server.password) stores it in an HttpOnly cookie. Scripts
+// can send it as "Authorization: Bearer <token>" instead.
+type serverAuth struct {
+	token    string
+	password string
+	// csrfToken is embedded in the page and must be echoed in the
+	// X-CSRF-Token header of cookie-authenticated POST requests.
+	csrfToken  string
+	cookieName string
+	secure     bool
+}
+
+const csrfHeader = "X-CSRF-Token"
+
+func newServerAuth(password, port string) (*serverAuth, error) {
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	csrfToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	return &serverAuth{
+		token:     token,
+		password:  password,
+		csrfToken: csrfToken,
+		// Cookies aren't scoped by port, so two servers on one host would
+		// otherwise overwrite each other's session
+		cookieName: "cmdo_session_" + port,
+	}, nil
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func tokensEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// middleware rejects cross-origin requests, handles the token URL and the
+// login form, and lets only authenticated requests through to next.
+func (a *serverAuth) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !sameOrigin(r) {
+			denyRequest(w, r, http.StatusForbidden, "Cross-origin request rejected")
+			return
+		}
+
+		if r.URL.Path == "/login" {
+			a.loginHandler(w, r)
+			return
+		}
+
+		if token := r.URL.Query().Get("token"); token != "" && r.Method == http.MethodGet {
+			if !tokensEqual(token, a.token) {
+				a.unauthorized(w, r)
+				return
+			}
+			a.setSessionCookie(w)
+
+			// Drop the token from the address bar and browser history
+			query := r.URL.Query()
+			query.Del("token")
+			target := r.URL.Path
+			if len(query) > 0 {
+				target += "?" + query.Encode()
+			}
+			http.Redirect(w, r, target, http.StatusSeeOther)
+			return
+		}
+
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			if !tokensEqual(bearer, a.token) {
+				a.unauthorized(w, r)
+				return
+			}
+			// Browsers never attach this header on their own, so these
+			// requests need no CSRF token
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cookie, err := r.Cookie(a.cookieName)
+		if err != nil || !tokensEqual(cookie.Value, a.token) {
+			a.unauthorized(w, r)
+			return
+		}
+
+		if !isSafeMethod(r.Method) && !tokensEqual(r.Header.Get(csrfHeader), a.csrfToken) {
+			denyRequest(w, r, http.StatusForbidden, "Missing or invalid CSRF token")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a *serverAuth) unauthorized(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet && !strings.HasPrefix(r.URL.Path, "/api/") && a.password != "" {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+	denyRequest(w, r, http.StatusUnauthorized, "Unauthorized: open the URL printed by 'cmdo serve'")
+}
+
+// denyRequest answers API requests with a JSON error and pages with text.
+func denyRequest(w http.ResponseWriter, r *http.Request, status int, message string) {
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		writeError(w, status, message)
+		return
+	}
+	http.Error(w, message, status)
+}
+
+func (a *serverAuth) setSessionCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     a.cookieName,
+		Value:    a.token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   a.secure,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+var loginTemplate = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="UTF-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1.0">
+  <title>CMDO - Log in</title>
+  <style>
+    body { background: hsl(220, 13%, 4%); color: hsl(210, 40%, 98%); font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', sans-serif;
+      display: flex; align-items: center; justify-content: center; min-height: 100vh; margin: 0; }
+    form { background: #161b22; border: 1px solid #30363d; border-radius: 8px; padding: 24px; width: 280px; }
+    h1 { font-size: 18px; margin: 0 0 16px; }
+    input, button { box-sizing: border-box; width: 100%; padding: 8px; border-radius: 6px; font-size: 14px; }
+    input { background: #0d1117; color: #c9d1d9; border: 1px solid #30363d; margin-bottom: 12px; }
+    button { background: #238636; color: #fff; border: 0; cursor: pointer; }
+    .error { color: #f85149; font-size: 13px; margin: 0 0 12px; }
+  </style>
+</head>
+<body>
+  <form method="POST" action="/login">
+    <h1>CMDO</h1>
+    {{if .Failed}}<p class="error">Wrong password</p>{{end}}
+    <input type="password" name="password" placeholder="Password" autofocus required>
+    <button type="submit">Log in</button>
+  </form>
+</body>
+</html>
+`))
+
+func (a *serverAuth) loginHandler(w http.ResponseWriter, r *http.Request) {
+	if a.password == "" {
+		http.Error(w, "Password login is disabled: set server.password or open the URL printed by 'cmdo serve'", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		loginTemplate.Execute(w, struct{ Failed bool }{false})
+	case http.MethodPost:
+		if !tokensEqual(r.PostFormValue("password"), a.password) {
+			log.Printf("Failed login from %s", r.RemoteAddr)
+			// Slow down guessing
+			time.Sleep(time.Second)
+			w.WriteHeader(http.StatusUnauthorized)
+			loginTemplate.Execute(w, struct{ Failed bool }{true})
+			return
+		}
+		a.setSessionCookie(w)
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// sameOrigin reports whether a request comes from the UI's own pages. The
+// server sends no CORS headers, so this only has to catch requests browsers
+// send cross-site anyway: form posts and simple fetches.
+func sameOrigin(r *http.Request) bool {
+	if r.Header.Get("Sec-Fetch-Site") == "cross-site" && !isSafeMethod(r.Method) {
+		return false
+	}
+
+	origin := r.Header.Get("Origin")
+	if origin == "" || origin == "null" && isSafeMethod(r.Method) {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
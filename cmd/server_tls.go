@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	selfSignedValidity = 365 * 24 * time.Hour
+	// A certificate closer than this to expiring is replaced on startup
+	selfSignedRenewBefore = 30 * 24 * time.Hour
+)
+
+// selfSignedPaths returns where `cmdo serve --tls-self-signed` keeps its
+// certificate and key.
+func selfSignedPaths() (string, string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", "", err
+	}
+	dir := filepath.Join(homeDir, ".cmdo")
+	return filepath.Join(dir, "serve-cert.pem"), filepath.Join(dir, "serve-key.pem"), nil
+}
+
+// ensureSelfSignedCert reuses the stored self-signed certificate while it is
+// valid for host, and generates a new one otherwise. It reports whether a
+// new certificate was created.
+func ensureSelfSignedCert(certFile, keyFile, host string) (bool, error) {
+	if cert, err := loadCertificate(certFile, keyFile); err == nil {
+		leaf := cert.Leaf
+		if time.Until(leaf.NotAfter) > selfSignedRenewBefore && leaf.VerifyHostname(host) == nil {
+			return false, nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(certFile), 0755); err != nil {
+		return false, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return false, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return false, err
+	}
+
+	hostname, _ := os.Hostname()
+	now := time.Now()
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "cmdo serve", Organization: []string{"cmdo"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	addCertHost(&template, "localhost")
+	addCertHost(&template, hostname)
+	addCertHost(&template, host)
+	// Cover every address of this machine so the certificate also works
+	// when the server is reached over the network
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok {
+				addCertHost(&template, ipNet.IP.String())
+			}
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return false, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return false, err
+	}
+
+	if err := writePEM(keyFile, "EC PRIVATE KEY", keyDER, 0600); err != nil {
+		return false, err
+	}
+	if err := writePEM(certFile, "CERTIFICATE", der, 0644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func addCertHost(template *x509.Certificate, host string) {
+	if host == "" {
+		return
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.IsUnspecified() {
+			return
+		}
+		for _, existing := range template.IPAddresses {
+			if existing.Equal(ip) {
+				return
+			}
+		}
+		template.IPAddresses = append(template.IPAddresses, ip)
+		return
+	}
+	for _, existing := range template.DNSNames {
+		if strings.EqualFold(existing, host) {
+			return
+		}
+	}
+	template.DNSNames = append(template.DNSNames, host)
+}
+
+func writePEM(path, blockType string, der []byte, perm os.FileMode) error {
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, perm); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file
+	return os.Chmod(path, perm)
+}
+
+// loadCertificate reads a PEM certificate and key pair, filling in Leaf.
+func loadCertificate(certFile, keyFile string) (tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return cert, err
+	}
+	if cert.Leaf == nil {
+		cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0])
+	}
+	return cert, err
+}
+
+// certFingerprint formats the SHA-256 hash of a certificate the way
+// browsers show it, so users can check it before accepting the certificate.
+func certFingerprint(cert tls.Certificate) string {
+	sum := sha256.Sum256(cert.Certificate[0])
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"github.com/tanu2534/cmdo/database"
+)
+
+var sessionCmd = &cobra.Command{
+	Use:   "session",
+	Short: "Inspect terminal sessions",
+	Long:  "session groups commands by the terminal they were run in. Shell hooks start a new session every time a shell starts.",
+}
+
+var sessionNewCmd = &cobra.Command{
+	Use:   "new",
+	Short: "Print a new session ID",
+	Long:  "new prints a fresh session ID. Shell hooks call it once at startup and pass the ID to every 'cmdo log'.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println(uuid.New().String())
+	},
+}
+
+var sessionListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List recent sessions",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		database.InitDB(database.GetGlobalDBPath())
+		defer database.DB.Close()
+
+		sessions, err := database.ListSessions(limit)
+		if err != nil {
+			fmt.Println("Error listing sessions:", err)
+			os.Exit(1)
+		}
+
+		if len(sessions) == 0 {
+			fmt.Println("No sessions recorded yet")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SESSION\tSTARTED\tLAST ACTIVITY\tCOMMANDS\tSHELL\tUSER@HOST\tTTY")
+		for _, s := range sessions {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s@%s\t%s\n",
+				shortSessionID(s.ID), s.StartedAt, s.LastActivity, s.CommandCount, s.Shell, s.User, s.Hostname, s.TTY)
+		}
+		w.Flush()
+	},
+}
+
+var sessionShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show the commands of one session",
+	Long:  "show prints a session's details and its commands in the order they ran. The ID may be abbreviated to a unique prefix.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		database.InitDB(database.GetGlobalDBPath())
+		defer database.DB.Close()
+
+		session, err := database.GetSession(args[0])
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+
+		commands, err := database.SessionCommands(session.ID)
+		if err != nil {
+			fmt.Println("Error loading commands:", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Session:  %s\n", session.ID)
+		fmt.Printf("Shell:    %s\n", session.Shell)
+		fmt.Printf("User:     %s@%s\n", session.User, session.Hostname)
+		if session.TTY != "" {
+			fmt.Printf("TTY:      %s\n", session.TTY)
+		}
+		fmt.Printf("Started:  %s\n", session.StartedAt)
+		fmt.Printf("Commands: %d\n\n", len(commands))
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TIME\tEXIT\tDIRECTORY\tCOMMAND")
+		for _, c := range commands {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Timestamp, exitCodeText(c.ExitCode), c.Directory, c.Command)
+		}
+		w.Flush()
+	},
+}
+
+// shortSessionID abbreviates a UUID to its first group, which is almost
+// always unique and is accepted by 'cmdo session show'.
+func shortSessionID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+func init() {
+	sessionListCmd.Flags().Int("limit", 20, "Maximum number of sessions")
+	sessionCmd.AddCommand(sessionNewCmd)
+	sessionCmd.AddCommand(sessionListCmd)
+	sessionCmd.AddCommand(sessionShowCmd)
+	rootCmd.AddCommand(sessionCmd)
+}
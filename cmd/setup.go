@@ -42,11 +42,81 @@ func identifyShell(shell string) shellInfo {
 			ConfigPath: filepath.Join(os.Getenv("USERPROFILE"), ".bashrc"),
 			Type:       "bash",
 		}
+	} else if filepath.Base(exepath) == "bash" {
+		return shellInfo{
+			Name:       "Bash",
+			ExePath:    exepath,
+			ConfigPath: getBashConfigPath(),
+			Type:       "bash",
+		}
+	} else if filepath.Base(exepath) == "zsh" {
+		return shellInfo{
+			Name:       "Zsh",
+			ExePath:    exepath,
+			ConfigPath: getZshConfigPath(),
+			Type:       "zsh",
+		}
+	} else if filepath.Base(exepath) == "fish" {
+		return shellInfo{
+			Name:       "Fish",
+			ExePath:    exepath,
+			ConfigPath: getFishHookPath(),
+			Type:       "fish",
+		}
 	}
 
 	return shellInfo{}
 }
 
+// getBashConfigPath returns the rc file bash reads on this OS. Linux
+// terminals start interactive non-login shells (~/.bashrc), while macOS
+// Terminal starts login shells that only read ~/.bash_profile.
+func getBashConfigPath() string {
+	homeDir, _ := os.UserHomeDir()
+	bashrc := filepath.Join(homeDir, ".bashrc")
+	bashProfile := filepath.Join(homeDir, ".bash_profile")
+
+	if runtime.GOOS == "darwin" {
+		if fileExists(bashProfile) || !fileExists(bashrc) {
+			return bashProfile
+		}
+		return bashrc
+	}
+
+	if !fileExists(bashrc) && fileExists(bashProfile) {
+		return bashProfile
+	}
+	return bashrc
+}
+
+// getZshConfigPath returns the .zshrc zsh will read, honoring $ZDOTDIR the
+// same way zsh itself does.
+func getZshConfigPath() string {
+	zdotdir := os.Getenv("ZDOTDIR")
+	if zdotdir == "" {
+		zdotdir, _ = os.UserHomeDir()
+	}
+	return filepath.Join(zdotdir, ".zshrc")
+}
+
+// getFishConfigDir returns fish's config directory, which follows
+// $XDG_CONFIG_HOME and defaults to ~/.config/fish.
+func getFishConfigDir() string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		homeDir, _ := os.UserHomeDir()
+		configHome = filepath.Join(homeDir, ".config")
+	}
+	return filepath.Join(configHome, "fish")
+}
+
+// getFishHookPath returns the conf.d snippet that holds the fish hook. fish
+// sources every file in conf.d on startup, so the hook lives in its own file
+// instead of being appended to config.fish.
+func getFishHookPath() string {
+	return filepath.Join(getFishConfigDir(), "conf.d", "cmdo.fish")
+}
+
 func getPowerShellProfile() string {
 	userProfile := os.Getenv("USERPROFILE")
 
@@ -69,10 +139,18 @@ func getPowerShellProfile() string {
 	return psCorePath
 }
 
-func getPowerShellHook(cmdoBinaryPath string) string {
+func getPowerShellHook(cmdoBinaryPath string, keyBindings bool) string {
+	bindings := ""
+	if keyBindings {
+		bindings = getPowerShellPickBinding(cmdoBinaryPath)
+	}
+
 	return fmt.Sprintf(`
 # CMDO Command Logger Hook
 $Global:__CmdoLastHistoryId = -1
+$Global:__CmdoSessionId = [guid]::NewGuid().ToString()
+$env:CMDO_SESSION = $Global:__CmdoSessionId
+$Global:__CmdoSessionStart = (Get-Date).ToString('o')
 
 function Invoke-CmdoLog {
     $history = Get-History -Count 1 -ErrorAction SilentlyContinue
@@ -83,10 +161,12 @@ function Invoke-CmdoLog {
         $exitCode = $LASTEXITCODE
         if ($null -eq $exitCode) { $exitCode = 0 }
         $currentDir = $PWD.Path
+        $startTime = $history.StartExecutionTime.ToString('o')
+        $duration = [long]($history.EndExecutionTime - $history.StartExecutionTime).TotalMilliseconds
         
         if ($lastCommand) {
             try {
-                & '%s' log --command "$lastCommand" --exit-code $exitCode --pwd "$currentDir" 2>$null
+                & '%s' log --command "$lastCommand" --exit-code $exitCode --pwd "$currentDir" --start $startTime --duration $duration --session $Global:__CmdoSessionId --session-start $Global:__CmdoSessionStart --shell powershell 2>$null
             } catch {
                 # Silently ignore logging errors
             }
@@ -94,6 +174,7 @@ function Invoke-CmdoLog {
     }
 }
 
+%s
 # Save original prompt if exists
 if (Test-Path Function:\prompt) {
     $Global:__CmdoOriginalPromptDef = ${function:prompt}.ToString()
@@ -111,76 +192,227 @@ function Global:prompt {
     # Default prompt if no original exists
     "PS $($executionContext.SessionState.Path.CurrentLocation)$('>' * ($nestedPromptLevel + 1)) "
 }
+`, cmdoBinaryPath, bindings)
+}
+
+func getPowerShellPickBinding(cmdoBinaryPath string) string {
+	return fmt.Sprintf(`# Ctrl+R: pick a command from cmdo history (needs PSReadLine)
+if (Get-Command Set-PSReadLineKeyHandler -ErrorAction SilentlyContinue) {
+    Set-PSReadLineKeyHandler -Chord Ctrl+r -ScriptBlock {
+        $line = $null
+        $cursor = $null
+        [Microsoft.PowerShell.PSConsoleReadLine]::GetBufferState([ref]$line, [ref]$cursor)
+        $selected = & '%s' pick --pwd $PWD.Path --query "$line"
+        if ($LASTEXITCODE -eq 0 -and $selected) {
+            [Microsoft.PowerShell.PSConsoleReadLine]::RevertLine()
+            [Microsoft.PowerShell.PSConsoleReadLine]::Insert($selected)
+        }
+    }
+}
 `, cmdoBinaryPath)
 }
 
-func getBashHook(cmdoBinaryPath string) string {
+func getBashHook(cmdoBinaryPath string, keyBindings bool) string {
 	// Windows paths ko Git Bash compatible format me convert karo
 	bashCompatiblePath := strings.ReplaceAll(cmdoBinaryPath, "\\", "/")
 
+	bindings := ""
+	if keyBindings {
+		bindings = getBashPickBinding(bashCompatiblePath)
+	}
+
 	return fmt.Sprintf(`
 # CMDO Command Logger Hook
+__cmdo_session=$("%[1]s" session new 2>/dev/null)
+__cmdo_session_start=${EPOCHREALTIME:-$(date +%%s)}
+export CMDO_SESSION=$__cmdo_session
+__cmdo_tty=$(tty 2>/dev/null) || __cmdo_tty=""
+
 function __cmdo_log() {
-    local last_command=$(history 1 | sed 's/^[ ]*[0-9]*[ ]*//')
     local exit_code=$?
+    local start_time=$__cmdo_start
+    unset __cmdo_start
+    local last_entry=$(HISTTIMEFORMAT= history 1)
     local current_dir=$(pwd)
     
-    if [ -n "$last_command" ]; then
-        "%s" log --command "$last_command" --exit-code $exit_code --pwd "$current_dir" 2>/dev/null
+    # Skip when the history number didn't move (empty line, Ctrl+C, HISTCONTROL);
+    # the command keeps any leading space so cmdo can apply ignorespace itself
+    if [[ "$last_entry" =~ ^\ *([0-9]+)[\*\ ]\ (.*)$ ]] && [ "${BASH_REMATCH[1]}" != "$__cmdo_last_hist_id" ]; then
+        __cmdo_last_hist_id=${BASH_REMATCH[1]}
+        "%[1]s" log --command "${BASH_REMATCH[2]}" --exit-code $exit_code --pwd "$current_dir" --start "$start_time" \
+            --session "$__cmdo_session" --session-start "$__cmdo_session_start" --tty "$__cmdo_tty" --shell bash 2>/dev/null
     fi
 }
 
+# Bash has no preexec, so the DEBUG trap records when the first command after a prompt starts
+function __cmdo_preexec() {
+    [ -n "$__cmdo_at_prompt" ] || return
+    unset __cmdo_at_prompt
+    __cmdo_start=${EPOCHREALTIME:-$(date +%%s)}
+}
+[ -z "$(trap -p DEBUG)" ] && trap '__cmdo_preexec' DEBUG
+
+%[2]s
 # Hook into PROMPT_COMMAND
 if [[ ! "$PROMPT_COMMAND" =~ "__cmdo_log" ]]; then
-    PROMPT_COMMAND="__cmdo_log${PROMPT_COMMAND:+; $PROMPT_COMMAND}"
+    PROMPT_COMMAND="__cmdo_log${PROMPT_COMMAND:+; $PROMPT_COMMAND}; __cmdo_at_prompt=1"
 fi
-`, bashCompatiblePath)
+`, bashCompatiblePath, bindings)
 }
 
-func addHookToConfigFile(shellInfo shellInfo, cmdoBinaryPath string) error {
+func getBashPickBinding(cmdoBinaryPath string) string {
+	return fmt.Sprintf(`# Ctrl-R: pick a command from cmdo history into the prompt
+function __cmdo_pick() {
+    local selected
+    selected=$("%s" pick --pwd "$PWD" --query "$READLINE_LINE") || return
+    READLINE_LINE=$selected
+    READLINE_POINT=${#READLINE_LINE}
+}
+[[ $- == *i* ]] && bind -x '"\C-r": __cmdo_pick'
+`, cmdoBinaryPath)
+}
+
+func getZshHook(cmdoBinaryPath string, keyBindings bool) string {
+	bindings := ""
+	if keyBindings {
+		bindings = getZshPickBinding(cmdoBinaryPath)
+	}
+
+	return fmt.Sprintf(`
+# CMDO Command Logger Hook
+autoload -Uz add-zsh-hook
+zmodload zsh/datetime 2>/dev/null
+__cmdo_session=$("%[1]s" session new 2>/dev/null)
+__cmdo_session_start="$EPOCHREALTIME"
+export CMDO_SESSION=$__cmdo_session
+
+# preexec receives the command line exactly as typed, before it runs
+function __cmdo_preexec() {
+    __cmdo_last_command="$1"
+    __cmdo_last_dir="$PWD"
+    __cmdo_start="$EPOCHREALTIME"
+}
+
+function __cmdo_precmd() {
+    local exit_code=$?
+
+    if [[ -n "$__cmdo_last_command" ]]; then
+        "%[1]s" log --command "$__cmdo_last_command" --exit-code $exit_code --pwd "$__cmdo_last_dir" --start "$__cmdo_start" \
+            --session "$__cmdo_session" --session-start "$__cmdo_session_start" --tty "$TTY" --shell zsh 2>/dev/null
+    fi
+    unset __cmdo_last_command __cmdo_last_dir __cmdo_start
+}
+
+%[2]s
+add-zsh-hook preexec __cmdo_preexec
+add-zsh-hook precmd __cmdo_precmd
+`, cmdoBinaryPath, bindings)
+}
+
+func getZshPickBinding(cmdoBinaryPath string) string {
+	return fmt.Sprintf(`# Ctrl-R: pick a command from cmdo history into the prompt
+function __cmdo_pick_widget() {
+    local selected
+    selected=$("%s" pick --pwd "$PWD" --query "$BUFFER") && BUFFER=$selected && CURSOR=${#BUFFER}
+    zle reset-prompt
+}
+zle -N __cmdo_pick_widget
+bindkey '^R' __cmdo_pick_widget
+`, cmdoBinaryPath)
+}
+
+func getFishHook(cmdoBinaryPath string, keyBindings bool) string {
+	quotedPath := strings.ReplaceAll(cmdoBinaryPath, "'", "\\'")
+
+	bindings := ""
+	if keyBindings {
+		bindings = getFishPickBinding(quotedPath)
+	}
+
+	return fmt.Sprintf(`
+# CMDO Command Logger Hook
+set -g __cmdo_session ('%[1]s' session new 2>/dev/null)
+set -g __cmdo_session_start (date +%%s)
+set -gx CMDO_SESSION $__cmdo_session
+set -g __cmdo_tty (tty 2>/dev/null); or set -g __cmdo_tty ""
+
+# fish_postexec fires after every interactive command with the command line as $argv[1]
+function __cmdo_postexec --on-event fish_postexec
+    set -l exit_code $status
+
+    if test -n "$argv[1]"
+        '%[1]s' log --command "$argv[1]" --exit-code $exit_code --pwd "$PWD" --duration $CMD_DURATION \
+            --session "$__cmdo_session" --session-start "$__cmdo_session_start" --tty "$__cmdo_tty" --shell fish 2>/dev/null
+    end
+end
+%[2]s`, quotedPath, bindings)
+}
+
+func getFishPickBinding(quotedBinaryPath string) string {
+	return fmt.Sprintf(`
+# Ctrl-R: pick a command from cmdo history into the prompt
+function __cmdo_pick
+    set -l selected ('%s' pick --pwd "$PWD" --query (commandline))
+    and commandline -r -- $selected
+    commandline -f repaint
+end
+bind \cr __cmdo_pick
+`, quotedBinaryPath)
+}
+
+// addHookToConfigFile installs the hook for a shell, or upgrades it in place
+// when an older version is installed. With dryRun it only prints the change
+// as a diff.
+func addHookToConfigFile(shellInfo shellInfo, cmdoBinaryPath string, keyBindings, dryRun bool) error {
 	var hookScript string
 
 	switch shellInfo.Type {
 	case "powershell":
-		hookScript = getPowerShellHook(cmdoBinaryPath)
+		hookScript = getPowerShellHook(cmdoBinaryPath, keyBindings)
 	case "bash":
-		hookScript = getBashHook(cmdoBinaryPath)
+		hookScript = getBashHook(cmdoBinaryPath, keyBindings)
+	case "zsh":
+		hookScript = getZshHook(cmdoBinaryPath, keyBindings)
+	case "fish":
+		hookScript = getFishHook(cmdoBinaryPath, keyBindings)
 	case "cmd":
 		return setupCMDHook(cmdoBinaryPath)
 	default:
 		return fmt.Errorf("unsupported shell type")
 	}
 
-	// Check if hook already exists
-	if fileExists(shellInfo.ConfigPath) {
-		content, err := os.ReadFile(shellInfo.ConfigPath)
-		if err != nil {
-			return err
-		}
-
-		if strings.Contains(string(content), "CMDO Command Logger Hook") {
-			fmt.Printf("Hook already exists in %s\n", shellInfo.ConfigPath)
-			return nil
-		}
-	} else {
-		// Create config file directory if needed
-		configDir := filepath.Dir(shellInfo.ConfigPath)
-		os.MkdirAll(configDir, 0755)
+	var original string
+	if content, err := os.ReadFile(shellInfo.ConfigPath); err == nil {
+		original = string(content)
+	} else if !os.IsNotExist(err) {
+		return err
 	}
 
-	// Append hook to config file
-	f, err := os.OpenFile(shellInfo.ConfigPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	updated, status, err := installHook(shellInfo.ConfigPath, original, renderHookBlock(hookScript))
 	if err != nil {
 		return err
 	}
-	defer f.Close()
 
-	_, err = f.WriteString("\n" + hookScript + "\n")
+	if status == "unchanged" {
+		fmt.Printf("Hook in %s is up to date\n", shellInfo.ConfigPath)
+		return nil
+	}
+
+	if dryRun {
+		fmt.Printf("Hook would be %s in %s:\n\n", status, shellInfo.ConfigPath)
+		fmt.Print(unifiedDiff(shellInfo.ConfigPath, original, updated))
+		return nil
+	}
+
+	backup, err := writeHookFile(shellInfo.ConfigPath, updated)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("Hook added to %s\n", shellInfo.ConfigPath)
+	fmt.Printf("Hook %s in %s\n", status, shellInfo.ConfigPath)
+	if backup != "" {
+		fmt.Printf("Backup saved to %s\n", backup)
+	}
 	return nil
 }
 
@@ -206,11 +438,23 @@ func getInstalledBinaryPath() (string, error) {
 
 	// Check if it's a temporary Go build path
 	if strings.Contains(exePath, "go-build") {
+		binaryName := "cmdo"
+		if runtime.GOOS == "windows" {
+			binaryName = "cmdo.exe"
+		}
+		homeDir, _ := os.UserHomeDir()
+
 		// Try to find installed binary in common locations
 		possiblePaths := []string{
-			filepath.Join(os.Getenv("GOPATH"), "bin", "cmdo.exe"),
-			filepath.Join(os.Getenv("USERPROFILE"), "go", "bin", "cmdo.exe"),
-			filepath.Join(os.Getenv("USERPROFILE"), "bin", "cmdo.exe"),
+			filepath.Join(os.Getenv("GOPATH"), "bin", binaryName),
+			filepath.Join(homeDir, "go", "bin", binaryName),
+			filepath.Join(homeDir, "bin", binaryName),
+		}
+		if runtime.GOOS != "windows" {
+			possiblePaths = append(possiblePaths,
+				filepath.Join(homeDir, ".local", "bin", binaryName),
+				filepath.Join("/usr", "local", "bin", binaryName),
+			)
 		}
 
 		for _, path := range possiblePaths {
@@ -230,14 +474,11 @@ var setupCmd = &cobra.Command{
 	Use:     "setup",
 	Aliases: []string{"setting"},
 	Short:   "Setting up the CMDO logger server",
-	Long:    "Use setup command in the binary for setting up the server locally.",
+	Long:    "Use setup command in the binary for setting up the server locally. Hooks are written between '# >>> cmdo >>>' and '# <<< cmdo <<<' markers; running setup again upgrades them in place. Every changed file is backed up first.",
 	Run: func(cmd *cobra.Command, args []string) {
 		currentOS := runtime.GOOS
-
-		if currentOS != "windows" {
-			fmt.Println("Currently only Windows is supported")
-			return
-		}
+		keyBindings, _ := cmd.Flags().GetBool("keybindings")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
 
 		fmt.Println("🔍 Detecting installed shells...")
 
@@ -246,8 +487,13 @@ var setupCmd = &cobra.Command{
 		if err != nil {
 			fmt.Printf("Error: %v\n", err)
 			fmt.Println("\nInstallation steps:")
-			fmt.Println("  1. Run: go build -o cmdo.exe")
-			fmt.Printf("  2. Copy cmdo.exe to: %%USERPROFILE%%\\bin\\\n")
+			if currentOS == "windows" {
+				fmt.Println("  1. Run: go build -o cmdo.exe")
+				fmt.Printf("  2. Copy cmdo.exe to: %%USERPROFILE%%\\bin\\\n")
+			} else {
+				fmt.Println("  1. Run: go build -o cmdo")
+				fmt.Println("  2. Copy cmdo to: ~/.local/bin/ (or anywhere on your $PATH)")
+			}
 			fmt.Println("  3. Or run: go install")
 			fmt.Println("  4. Then run: cmdo setup")
 			return
@@ -255,77 +501,11 @@ var setupCmd = &cobra.Command{
 
 		fmt.Printf("✓ Using binary: %s\n\n", cmdoBinaryPath)
 
-		userProfile := os.Getenv("USERPROFILE")
 		var foundShells []string
-
-		// Dotnet Global Tools
-		dotnetPwsh := filepath.Join(userProfile, ".dotnet", "tools", "pwsh.exe")
-		if _, err := os.Stat(dotnetPwsh); err == nil {
-			foundShells = append(foundShells, dotnetPwsh)
-			fmt.Println("Found Dotnet PowerShell:", dotnetPwsh)
-		}
-
-		// Scoop
-		scoopPwsh := filepath.Join(userProfile, "scoop", "shims", "pwsh.exe")
-		if _, err := os.Stat(scoopPwsh); err == nil {
-			foundShells = append(foundShells, scoopPwsh)
-			fmt.Println("Found Scoop PowerShell:", scoopPwsh)
-		}
-
-		// CMD
-		cmdPath := filepath.Join(os.Getenv("SystemRoot"), "System32", "cmd.exe")
-		if _, err := os.Stat(cmdPath); err == nil {
-			foundShells = append(foundShells, cmdPath)
-			fmt.Println("Found CMD:", cmdPath)
-		}
-
-		// Use 'where' command to find PowerShell/pwsh
-		if cmdPath != "" {
-			cmd := exec.Command("where", "powershell")
-			output, err := cmd.Output()
-			if err == nil {
-				paths := strings.Split(strings.TrimSpace(string(output)), "\n")
-				for _, path := range paths {
-					path = strings.TrimSpace(path)
-					if path != "" {
-						fmt.Println("Found PowerShell via 'where':", path)
-						foundShells = append(foundShells, path)
-					}
-				}
-			}
-
-			cmd = exec.Command("where", "pwsh")
-			output, err = cmd.Output()
-			if err == nil {
-				paths := strings.Split(strings.TrimSpace(string(output)), "\n")
-				for _, path := range paths {
-					path = strings.TrimSpace(path)
-					if path != "" {
-						fmt.Println("Found pwsh via 'where':", path)
-						foundShells = append(foundShells, path)
-					}
-				}
-			}
-		}
-
-		// Git Bash
-		gitBashPath := "C:\\Program Files\\Git\\bin\\bash.exe"
-		if _, err := os.Stat(gitBashPath); err == nil {
-			foundShells = append(foundShells, gitBashPath)
-			fmt.Println("Found Git Bash:", gitBashPath)
-		}
-
-		// WSL check
-		wslCmd := exec.Command("wsl.exe", "--list", "--quiet")
-		output, err := wslCmd.Output()
-		if err == nil {
-			distros := strings.Split(string(output), "\n")
-			for _, distro := range distros {
-				distro = strings.TrimSpace(distro)
-				if distro != "" {
-					fmt.Println("Found WSL distro:", distro)
-				}
-			}
+		if currentOS == "windows" {
+			foundShells = detectWindowsShells()
+		} else {
+			foundShells = detectUnixShells()
 		}
 
 		fmt.Printf("\n✓ Total shells found: %d\n", len(foundShells))
@@ -337,6 +517,9 @@ var setupCmd = &cobra.Command{
 
 		fmt.Println("\nInstalling hooks...")
 
+		// Several shell paths (e.g. /bin/bash and /usr/bin/bash) share one rc file
+		configured := make(map[string]bool)
+
 		for _, shellPath := range foundShells {
 			shellInfo := identifyShell(shellPath)
 
@@ -344,22 +527,165 @@ var setupCmd = &cobra.Command{
 				continue
 			}
 
+			configKey := shellInfo.Type + ":" + shellInfo.ConfigPath
+			if configured[configKey] {
+				continue
+			}
+			configured[configKey] = true
+
 			fmt.Printf("\n Setting up %s...\n", shellInfo.Name)
 
-			err := addHookToConfigFile(shellInfo, cmdoBinaryPath)
+			err := addHookToConfigFile(shellInfo, cmdoBinaryPath, keyBindings, dryRun)
 			if err != nil {
 				fmt.Printf("Error setting up %s: %v\n", shellInfo.Name, err)
 			}
 		}
 
+		if dryRun {
+			fmt.Println("\nDry run: no files were changed")
+			return
+		}
+
 		fmt.Println("\nSetup complete!")
 		fmt.Println("\nNext steps:")
 		fmt.Println("  1. Restart your terminal, OR")
-		fmt.Println("  2. For Bash: source ~/.bashrc")
-		fmt.Println("  3. For PowerShell: . $PROFILE")
+		if currentOS == "windows" {
+			fmt.Println("  2. For Bash: source ~/.bashrc")
+			fmt.Println("  3. For PowerShell: . $PROFILE")
+		} else {
+			fmt.Printf("  2. For Bash: source %s\n", getBashConfigPath())
+			fmt.Printf("  3. For Zsh: source %s\n", getZshConfigPath())
+			fmt.Println("  4. For Fish: exec fish")
+		}
 	},
 }
 
+func detectWindowsShells() []string {
+	userProfile := os.Getenv("USERPROFILE")
+	var foundShells []string
+
+	// Dotnet Global Tools
+	dotnetPwsh := filepath.Join(userProfile, ".dotnet", "tools", "pwsh.exe")
+	if _, err := os.Stat(dotnetPwsh); err == nil {
+		foundShells = append(foundShells, dotnetPwsh)
+		fmt.Println("Found Dotnet PowerShell:", dotnetPwsh)
+	}
+
+	// Scoop
+	scoopPwsh := filepath.Join(userProfile, "scoop", "shims", "pwsh.exe")
+	if _, err := os.Stat(scoopPwsh); err == nil {
+		foundShells = append(foundShells, scoopPwsh)
+		fmt.Println("Found Scoop PowerShell:", scoopPwsh)
+	}
+
+	// CMD
+	cmdPath := filepath.Join(os.Getenv("SystemRoot"), "System32", "cmd.exe")
+	if _, err := os.Stat(cmdPath); err == nil {
+		foundShells = append(foundShells, cmdPath)
+		fmt.Println("Found CMD:", cmdPath)
+	}
+
+	// Use 'where' command to find PowerShell/pwsh
+	if cmdPath != "" {
+		cmd := exec.Command("where", "powershell")
+		output, err := cmd.Output()
+		if err == nil {
+			paths := strings.Split(strings.TrimSpace(string(output)), "\n")
+			for _, path := range paths {
+				path = strings.TrimSpace(path)
+				if path != "" {
+					fmt.Println("Found PowerShell via 'where':", path)
+					foundShells = append(foundShells, path)
+				}
+			}
+		}
+
+		cmd = exec.Command("where", "pwsh")
+		output, err = cmd.Output()
+		if err == nil {
+			paths := strings.Split(strings.TrimSpace(string(output)), "\n")
+			for _, path := range paths {
+				path = strings.TrimSpace(path)
+				if path != "" {
+					fmt.Println("Found pwsh via 'where':", path)
+					foundShells = append(foundShells, path)
+				}
+			}
+		}
+	}
+
+	// Git Bash
+	gitBashPath := "C:\\Program Files\\Git\\bin\\bash.exe"
+	if _, err := os.Stat(gitBashPath); err == nil {
+		foundShells = append(foundShells, gitBashPath)
+		fmt.Println("Found Git Bash:", gitBashPath)
+	}
+
+	// WSL check
+	wslCmd := exec.Command("wsl.exe", "--list", "--quiet")
+	output, err := wslCmd.Output()
+	if err == nil {
+		distros := strings.Split(string(output), "\n")
+		for _, distro := range distros {
+			distro = strings.TrimSpace(distro)
+			if distro != "" {
+				fmt.Println("Found WSL distro:", distro)
+			}
+		}
+	}
+
+	return foundShells
+}
+
+// detectUnixShells looks at the login shell ($SHELL), the system-wide list of
+// valid shells (/etc/shells) and the rc files in the home directory, since a
+// user may have bash configured without it being their login shell.
+func detectUnixShells() []string {
+	var foundShells []string
+
+	if loginShell := os.Getenv("SHELL"); loginShell != "" {
+		foundShells = append(foundShells, loginShell)
+		fmt.Println("Found login shell ($SHELL):", loginShell)
+	}
+
+	if content, err := os.ReadFile("/etc/shells"); err == nil {
+		for _, line := range strings.Split(string(content), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if fileExists(line) && identifyShell(line).Type != "" {
+				foundShells = append(foundShells, line)
+				fmt.Println("Found shell in /etc/shells:", line)
+			}
+		}
+	}
+
+	homeDir, _ := os.UserHomeDir()
+	rcFiles := []struct {
+		Path  string
+		Shell string
+	}{
+		{filepath.Join(homeDir, ".bashrc"), "bash"},
+		{filepath.Join(homeDir, ".bash_profile"), "bash"},
+		{getZshConfigPath(), "zsh"},
+		{getFishConfigDir(), "fish"},
+	}
+	for _, rc := range rcFiles {
+		if !fileExists(rc.Path) {
+			continue
+		}
+		fmt.Printf("Found %s config: %s\n", rc.Shell, rc.Path)
+		if shellPath, err := exec.LookPath(rc.Shell); err == nil {
+			foundShells = append(foundShells, shellPath)
+		}
+	}
+
+	return foundShells
+}
+
 func init() {
+	setupCmd.Flags().Bool("keybindings", true, "Bind Ctrl-R to 'cmdo pick' in each shell")
+	setupCmd.Flags().Bool("dry-run", false, "Show the changes to each config file as a diff without writing them")
 	rootCmd.AddCommand(setupCmd)
 }
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tanu2534/cmdo/database"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show command history statistics",
+	Long:  "stats shows the most used commands, failure rates per folder, the busiest hours of the day and daily activity.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		since, _ := cmd.Flags().GetString("since")
+		top, _ := cmd.Flags().GetInt("top")
+		days, _ := cmd.Flags().GetInt("days")
+
+		opts := database.StatsOptions{Top: top, Days: days}
+		if since != "" {
+			t, err := parseTimeFlag(since, time.Now())
+			if err != nil {
+				fmt.Println("Error: invalid --since:", err)
+				os.Exit(1)
+			}
+			opts.Since = t
+		}
+
+		database.InitDB(database.GetGlobalDBPath())
+		defer database.DB.Close()
+
+		stats, err := database.GetStats(opts)
+		if err != nil {
+			fmt.Println("Error computing stats:", err)
+			os.Exit(1)
+		}
+
+		if stats.TotalCommands == 0 {
+			fmt.Println("No commands logged yet")
+			return
+		}
+
+		printStats(stats)
+	},
+}
+
+func printStats(stats database.Stats) {
+	fmt.Printf("📊 %d commands, %d failed (%.1f%%)\n",
+		stats.TotalCommands, stats.FailedCommands, 100*float64(stats.FailedCommands)/float64(stats.TotalCommands))
+
+	fmt.Println("\n🏆 Top commands")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for i, c := range stats.TopCommands {
+		fmt.Fprintf(w, "   %d.\t%s\t%d\t%s\n", i+1, c.Executable, c.Count, bar(c.Count, stats.TopCommands[0].Count, 30))
+	}
+	w.Flush()
+
+	fmt.Println("\n📁 Failure rate by folder")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, f := range stats.Folders {
+		fmt.Fprintf(w, "   %5.1f%%\t%d/%d\t%s\n", 100*f.FailureRate, f.Failed, f.Total, f.Folder)
+	}
+	w.Flush()
+
+	fmt.Println("\n🕐 Busiest hours")
+	busiest := 0
+	for _, n := range stats.Hours {
+		busiest = max(busiest, n)
+	}
+	for hour, n := range stats.Hours {
+		if n == 0 {
+			continue
+		}
+		fmt.Printf("   %02d:00  %-30s %d\n", hour, bar(n, busiest, 30), n)
+	}
+
+	counts := make([]int, len(stats.Days))
+	total := 0
+	for i, d := range stats.Days {
+		counts[i] = d.Count
+		total += d.Count
+	}
+	if len(stats.Days) > 0 {
+		fmt.Printf("\n📅 Last %d days (%s → %s), %d commands\n", len(stats.Days),
+			stats.Days[0].Day, stats.Days[len(stats.Days)-1].Day, total)
+		fmt.Printf("   %s\n", sparkline(counts))
+	}
+}
+
+// bar draws a horizontal bar of up to width cells proportional to n/maxN.
+func bar(n, maxN, width int) string {
+	if maxN == 0 {
+		return ""
+	}
+	cells := n * width / maxN
+	if cells == 0 && n > 0 {
+		cells = 1
+	}
+	return strings.Repeat("█", cells)
+}
+
+// sparkline renders values as a row of block characters scaled to the
+// largest value; zero is drawn as the lowest block.
+func sparkline(values []int) string {
+	blocks := []rune("▁▂▃▄▅▆▇█")
+
+	maxValue := 0
+	for _, v := range values {
+		maxValue = max(maxValue, v)
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if maxValue > 0 {
+			i = v * (len(blocks) - 1) / maxValue
+		}
+		b.WriteRune(blocks[i])
+	}
+	return b.String()
+}
+
+func init() {
+	statsCmd.Flags().String("since", "", "Only count commands logged at or after this time (e.g. 30d, 2024-01-31)")
+	statsCmd.Flags().Int("top", 10, "Number of commands and folders to list")
+	statsCmd.Flags().Int("days", 30, "Number of days in the daily activity sparkline")
+	rootCmd.AddCommand(statsCmd)
+}
@@ -0,0 +1,340 @@
+// Package config loads cmdo settings from ~/.cmdo/config.toml and CMDO_*
+// environment variables. Command-line flags override both; commands apply
+// them on top of the loaded Config.
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Config holds every setting. Its toml tags double as the dotted keys used
+// by `cmdo config get/set` ("server.port") and, upper-cased with dots turned
+// into underscores, as the CMDO_* environment variables (CMDO_SERVER_PORT).
+type Config struct {
+	DBPath string `toml:"db_path"`
+
+	Server    ServerConfig    `toml:"server"`
+	Ignore    IgnoreConfig    `toml:"ignore"`
+	Redact    RedactConfig    `toml:"redact"`
+	Retention RetentionConfig `toml:"retention"`
+
+	// PausedSessions lists shells in which logging is paused ("*" for all).
+	PausedSessions []string `toml:"paused_sessions"`
+}
+
+type ServerConfig struct {
+	// Bind is the address `cmdo serve` listens on; empty or 0.0.0.0 means
+	// all interfaces.
+	Bind string `toml:"bind"`
+	Port int    `toml:"port"`
+	// Password lets browsers log in without the per-launch token URL.
+	Password string `toml:"password"`
+	// TLSCert and TLSKey are PEM files to serve HTTPS with. TLSSelfSigned
+	// generates a certificate under ~/.cmdo/ instead.
+	TLSCert       string `toml:"tls_cert"`
+	TLSKey        string `toml:"tls_key"`
+	TLSSelfSigned bool   `toml:"tls_self_signed"`
+}
+
+type IgnoreConfig struct {
+	// Patterns are matched by the ignore package; see `cmdo ignore --help`.
+	Patterns    []string `toml:"patterns"`
+	IgnoreSpace bool     `toml:"ignore_space"`
+}
+
+type RedactConfig struct {
+	// Patterns are extra regular expressions on top of the built-in rules.
+	Patterns    []string `toml:"patterns"`
+	HighEntropy bool     `toml:"high_entropy"`
+}
+
+type RetentionConfig struct {
+	// MaxAge drops commands older than this age ("90d", "12w"); empty keeps
+	// everything.
+	MaxAge string `toml:"max_age"`
+	// KeepLast keeps only the newest N commands; 0 means no limit.
+	KeepLast int `toml:"keep_last"`
+	// VacuumThreshold is how many commands a prune has to remove before the
+	// database file is compacted.
+	VacuumThreshold int `toml:"vacuum_threshold"`
+}
+
+// Default returns the settings used when nothing is configured.
+func Default() *Config {
+	homeDir, _ := os.UserHomeDir()
+	return &Config{
+		DBPath:    filepath.Join(homeDir, ".cmdo", "cmdo.db"),
+		Server:    ServerConfig{Bind: "127.0.0.1", Port: 8089},
+		Ignore:    IgnoreConfig{IgnoreSpace: true},
+		Redact:    RedactConfig{HighEntropy: true},
+		Retention: RetentionConfig{VacuumThreshold: 1000},
+	}
+}
+
+// Path returns the config file location, $CMDO_CONFIG or ~/.cmdo/config.toml.
+func Path() string {
+	if path := os.Getenv("CMDO_CONFIG"); path != "" {
+		return path
+	}
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".cmdo", "config.toml")
+}
+
+var current *Config
+
+// Get returns the configuration loaded by Init, or the file and environment
+// settings if Init was never called (or failed).
+func Get() *Config {
+	if current == nil {
+		cfg, err := Load()
+		if err != nil {
+			cfg = Default()
+		}
+		current = cfg
+	}
+	return current
+}
+
+// Init loads the configuration once for the rest of the process.
+func Init() error {
+	cfg, err := Load()
+	if err != nil {
+		return err
+	}
+	current = cfg
+	return nil
+}
+
+// Load reads the config file over the defaults and then applies CMDO_*
+// environment variables.
+func Load() (*Config, error) {
+	cfg, err := LoadFile()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range Keys() {
+		value, ok := os.LookupEnv(EnvName(key))
+		if !ok {
+			continue
+		}
+		if err := cfg.Set(key, value); err != nil {
+			return nil, fmt.Errorf("%s: %w", EnvName(key), err)
+		}
+	}
+
+	return cfg, nil
+}
+
+// LoadFile reads only the config file over the defaults, for commands that
+// rewrite it and must not persist environment overrides.
+func LoadFile() (*Config, error) {
+	cfg := Default()
+
+	path := Path()
+	meta, err := toml.DecodeFile(path, cfg)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		return nil, fmt.Errorf("error reading %s: unknown key %q", path, undecoded[0].String())
+	}
+
+	cfg.DBPath = expandHome(cfg.DBPath)
+	return cfg, nil
+}
+
+// Save writes the settings of cfg that differ from the defaults to the
+// config file, so defaults such as the home-relative db_path keep following
+// the environment.
+func Save(cfg *Config) error {
+	defaults := Default()
+	doc := map[string]any{}
+	for _, key := range Keys() {
+		value, _ := cfg.field(key)
+		defaultValue, _ := defaults.field(key)
+		if reflect.DeepEqual(value.Interface(), defaultValue.Interface()) ||
+			value.Kind() == reflect.Slice && value.Len() == 0 && defaultValue.Len() == 0 {
+			continue
+		}
+
+		table := doc
+		parts := strings.Split(key, ".")
+		for _, part := range parts[:len(parts)-1] {
+			if _, ok := table[part]; !ok {
+				table[part] = map[string]any{}
+			}
+			table = table[part].(map[string]any)
+		}
+		table[parts[len(parts)-1]] = value.Interface()
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("# cmdo configuration, see 'cmdo config list' for every key\n\n")
+	if err := toml.NewEncoder(&buf).Encode(doc); err != nil {
+		return err
+	}
+
+	path := Path()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	// The file can hold server.password, so keep it private
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file
+	return os.Chmod(path, 0600)
+}
+
+// Update applies change to the config file and reloads the configuration.
+func Update(change func(cfg *Config) error) error {
+	cfg, err := LoadFile()
+	if err != nil {
+		return err
+	}
+	if err := change(cfg); err != nil {
+		return err
+	}
+	if err := Save(cfg); err != nil {
+		return err
+	}
+	return Init()
+}
+
+// EnvName returns the environment variable that overrides key.
+func EnvName(key string) string {
+	return "CMDO_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// Keys returns every settable key in sorted order.
+func Keys() []string {
+	var keys []string
+	walk(reflect.ValueOf(Default()).Elem(), "", func(key string, _ reflect.Value) {
+		keys = append(keys, key)
+	})
+	sort.Strings(keys)
+	return keys
+}
+
+// Get returns the value of key formatted the way Set accepts it.
+func (c *Config) Get(key string) (string, error) {
+	v, ok := c.field(key)
+	if !ok {
+		return "", fmt.Errorf("unknown config key %q", key)
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.Len() == 0 {
+			return "[]", nil
+		}
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(map[string]any{"v": v.Interface()}); err != nil {
+			return "", err
+		}
+		return strings.TrimPrefix(strings.TrimSpace(buf.String()), "v = "), nil
+	default:
+		return fmt.Sprint(v.Interface()), nil
+	}
+}
+
+// Set parses value for key. Lists take a TOML array (["ls", "cd *"]); any
+// other value becomes a one-element list, and an empty value an empty list.
+func (c *Config) Set(key, value string) error {
+	v, ok := c.field(key)
+	if !ok {
+		return fmt.Errorf("unknown config key %q", key)
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		if key == "db_path" {
+			value = expandHome(value)
+		}
+		v.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s must be an integer", key)
+		}
+		v.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s must be true or false", key)
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		list, err := parseList(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		v.Set(reflect.ValueOf(list))
+	}
+	return nil
+}
+
+func (c *Config) field(key string) (reflect.Value, bool) {
+	var found reflect.Value
+	walk(reflect.ValueOf(c).Elem(), "", func(k string, v reflect.Value) {
+		if k == key {
+			found = v
+		}
+	})
+	return found, found.IsValid()
+}
+
+// walk calls fn for every leaf field of v with its dotted key.
+func walk(v reflect.Value, prefix string, fn func(key string, v reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key := prefix + t.Field(i).Tag.Get("toml")
+		if v.Field(i).Kind() == reflect.Struct {
+			walk(v.Field(i), key+".", fn)
+			continue
+		}
+		fn(key, v.Field(i))
+	}
+}
+
+func parseList(value string) ([]string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(value, "[") {
+		return []string{value}, nil
+	}
+
+	var doc struct {
+		V []string `toml:"v"`
+	}
+	if _, err := toml.Decode("v = "+value, &doc); err != nil {
+		return nil, fmt.Errorf("invalid list %s", value)
+	}
+	if doc.V == nil {
+		doc.V = []string{}
+	}
+	return doc.V, nil
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, `~\`) {
+		homeDir, _ := os.UserHomeDir()
+		return filepath.Join(homeDir, path[1:])
+	}
+	return path
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetGet(t *testing.T) {
+	tests := []struct {
+		key     string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"server.port", "9000", "9000", false},
+		{"server.port", "nine", "", true},
+		{"server.bind", "0.0.0.0", "0.0.0.0", false},
+		{"ignore.ignore_space", "false", "false", false},
+		{"ignore.ignore_space", "maybe", "", true},
+		{"ignore.patterns", `["ls", "cd *"]`, `["ls", "cd *"]`, false},
+		{"ignore.patterns", "ls", `["ls"]`, false},
+		{"ignore.patterns", "", "[]", false},
+		{"ignore.patterns", `["ls"`, "", true},
+		{"no.such.key", "1", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			cfg := Default()
+			err := cfg.Set(tt.key, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Set error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got, err := cfg.Get(tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Get = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		env      map[string]string
+		wantPort int
+		wantErr  string
+	}{
+		{"no file", "", nil, 8089, ""},
+		{"file", "[server]\nport = 9000\n", nil, 9000, ""},
+		{"environment beats file", "[server]\nport = 9000\n", map[string]string{"CMDO_SERVER_PORT": "9100"}, 9100, ""},
+		{"invalid environment", "", map[string]string{"CMDO_SERVER_PORT": "x"}, 0, "CMDO_SERVER_PORT"},
+		{"unknown key", "[server]\nprot = 9000\n", nil, 0, "unknown key"},
+		{"broken file", "[server\n", nil, 0, "error reading"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if tt.file != "" {
+				if err := os.WriteFile(path, []byte(tt.file), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Setenv("CMDO_CONFIG", path)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg, err := Load()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Server.Port != tt.wantPort {
+				t.Errorf("port = %d, want %d", cfg.Server.Port, tt.wantPort)
+			}
+		})
+	}
+}
+
+func TestSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	t.Setenv("CMDO_CONFIG", path)
+
+	// An existing world-readable file is tightened too
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := Default()
+	cfg.Server.Password = "hunter2"
+	cfg.Ignore.Patterns = []string{"ls"}
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("config file mode = %o, want 600", perm)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "port") || strings.Contains(string(data), "db_path") {
+		t.Errorf("defaults were written:\n%s", data)
+	}
+
+	loaded, err := LoadFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Server.Password != "hunter2" || len(loaded.Ignore.Patterns) != 1 {
+		t.Errorf("reloaded %+v", loaded)
+	}
+}
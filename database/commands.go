@@ -0,0 +1,352 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PageCursor marks the last row of a page in (timestamp DESC, id DESC) order,
+// so the next page starts right after it even while new rows are inserted.
+type PageCursor struct {
+	Timestamp string
+	ID        int
+}
+
+// Encode returns an opaque, URL-safe form of the cursor.
+func (c PageCursor) Encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.Timestamp + "|" + strconv.Itoa(c.ID)))
+}
+
+// DecodePageCursor parses a cursor produced by PageCursor.Encode.
+func DecodePageCursor(s string) (PageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return PageCursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return PageCursor{}, fmt.Errorf("invalid cursor")
+	}
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return PageCursor{}, fmt.Errorf("invalid cursor")
+	}
+	return PageCursor{Timestamp: ts, ID: n}, nil
+}
+
+// ListCommands returns one page of commands matching opts, newest first,
+// starting after the given cursor (nil for the first page). Query is a
+// case-insensitive substring match on the command or its directory. It also
+// returns the number of matching rows across all pages and the cursor of the
+// next page, which is nil on the last page.
+func ListCommands(opts SearchOptions, after *PageCursor) ([]Command, int, *PageCursor, error) {
+	if DB == nil {
+		return nil, 0, nil, sql.ErrConnDone
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = 100
+	}
+
+	where, args := opts.where("")
+	if q := strings.TrimSpace(opts.Query); q != "" {
+		where += ` AND (command LIKE ? ESCAPE '\' OR directory LIKE ? ESCAPE '\')`
+		pattern := "%" + escapeLike(q) + "%"
+		args = append(args, pattern, pattern)
+	}
+
+	var total int
+	if err := DB.QueryRow("SELECT COUNT(*) FROM commands WHERE "+where, args...).Scan(&total); err != nil {
+		return nil, 0, nil, err
+	}
+
+	pageWhere := where
+	pageArgs := append([]any{}, args...)
+	if after != nil {
+		pageWhere += " AND (timestamp < ? OR (timestamp = ? AND id < ?))"
+		pageArgs = append(pageArgs, after.Timestamp, after.Timestamp, after.ID)
+	}
+
+	// Fetch one extra row to learn whether another page exists
+	query := `SELECT ` + commandColumns("") + `
+		FROM commands WHERE ` + pageWhere + `
+		ORDER BY timestamp DESC, id DESC LIMIT ?`
+	pageArgs = append(pageArgs, opts.Limit+1)
+
+	commands, err := queryCommands(query, pageArgs...)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	var next *PageCursor
+	if len(commands) > opts.Limit {
+		commands = commands[:opts.Limit]
+		last := commands[len(commands)-1]
+		next = &PageCursor{Timestamp: last.Timestamp, ID: last.ID}
+	}
+
+	return commands, total, next, nil
+}
+
+// CommandsAfter returns the commands matching opts whose id is above afterID,
+// oldest first and at most opts.Limit (default 100) of them. Query matches
+// like in ListCommands.
+func CommandsAfter(opts SearchOptions, afterID int) ([]Command, error) {
+	if DB == nil {
+		return nil, sql.ErrConnDone
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = 100
+	}
+
+	where, args := opts.where("")
+	if q := strings.TrimSpace(opts.Query); q != "" {
+		where += ` AND (command LIKE ? ESCAPE '\' OR directory LIKE ? ESCAPE '\')`
+		pattern := "%" + escapeLike(q) + "%"
+		args = append(args, pattern, pattern)
+	}
+	args = append(args, afterID, opts.Limit)
+
+	return queryCommands(`SELECT `+commandColumns("")+`
+		FROM commands WHERE `+where+` AND id > ?
+		ORDER BY id LIMIT ?`, args...)
+}
+
+// MaxCommandID returns the highest command id, 0 when there are none.
+func MaxCommandID() (int, error) {
+	if DB == nil {
+		return 0, sql.ErrConnDone
+	}
+
+	var id sql.NullInt64
+	err := DB.QueryRow("SELECT MAX(id) FROM commands").Scan(&id)
+	return int(id.Int64), err
+}
+
+// GetCommand returns the command with the given id, or sql.ErrNoRows.
+func GetCommand(id int) (Command, error) {
+	if DB == nil {
+		return Command{}, sql.ErrConnDone
+	}
+
+	commands, err := queryCommands(`SELECT `+commandColumns("")+` FROM commands WHERE id = ?`, id)
+	if err != nil {
+		return Command{}, err
+	}
+	if len(commands) == 0 {
+		return Command{}, sql.ErrNoRows
+	}
+	return commands[0], nil
+}
+
+// CommandUpdate lists the fields UpdateCommand changes; nil ones are kept.
+type CommandUpdate struct {
+	Command   *string
+	ExitCode  *int
+	Directory *string
+}
+
+// UpdateCommand changes the given fields of a command and returns the
+// result, or sql.ErrNoRows when there is no such command.
+func UpdateCommand(id int, update CommandUpdate) (Command, error) {
+	if DB == nil {
+		return Command{}, sql.ErrConnDone
+	}
+
+	var sets []string
+	var args []any
+	if update.Command != nil {
+		sets = append(sets, "command = ?")
+		args = append(args, *update.Command)
+	}
+	if update.ExitCode != nil {
+		sets = append(sets, "exit_code = ?")
+		args = append(args, strconv.Itoa(*update.ExitCode))
+	}
+	if update.Directory != nil {
+		sets = append(sets, "directory = ?")
+		args = append(args, *update.Directory)
+	}
+
+	if len(sets) > 0 {
+		result, err := DB.Exec("UPDATE commands SET "+strings.Join(sets, ", ")+" WHERE id = ?", append(args, id)...)
+		if err != nil {
+			return Command{}, err
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return Command{}, sql.ErrNoRows
+		}
+	}
+	return GetCommand(id)
+}
+
+// DeleteCommands deletes the commands with the given ids, along with
+// sessions left without commands, and returns how many were found.
+func DeleteCommands(ids []int) (int, error) {
+	if DB == nil {
+		return 0, sql.ErrConnDone
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("DELETE FROM commands WHERE id = ?")
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	removed := 0
+	for _, id := range ids {
+		result, err := stmt.Exec(id)
+		if err != nil {
+			return 0, fmt.Errorf("error deleting command %d: %w", id, err)
+		}
+		n, _ := result.RowsAffected()
+		removed += int(n)
+	}
+
+	if removed > 0 {
+		if _, err := tx.Exec(`DELETE FROM sessions WHERE id NOT IN (
+			SELECT session_id FROM commands WHERE session_id IS NOT NULL)`); err != nil {
+			return 0, err
+		}
+	}
+	return removed, tx.Commit()
+}
+
+// DeleteAllCommands empties the history, sessions included, and returns how
+// many commands it deleted.
+func DeleteAllCommands() (int, error) {
+	if DB == nil {
+		return 0, sql.ErrConnDone
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM commands")
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("DELETE FROM sessions"); err != nil {
+		return 0, err
+	}
+	n, _ := result.RowsAffected()
+	return int(n), tx.Commit()
+}
+
+// CommandRewrite is a command whose text RewriteCommands changed.
+type CommandRewrite struct {
+	ID     int
+	Before string
+	After  string
+}
+
+// RewriteCommands passes every stored command through rewrite and returns the
+// ones it changed. The new text is only written back when apply is true, in
+// a single transaction.
+func RewriteCommands(rewrite func(string) string, apply bool) ([]CommandRewrite, error) {
+	if DB == nil {
+		return nil, sql.ErrConnDone
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id, command FROM commands ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []CommandRewrite
+	for rows.Next() {
+		var c CommandRewrite
+		if err := rows.Scan(&c.ID, &c.Before); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if c.After = rewrite(c.Before); c.After != c.Before {
+			changed = append(changed, c)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if !apply {
+		return changed, nil
+	}
+
+	stmt, err := tx.Prepare("UPDATE commands SET command = ? WHERE id = ?")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	for _, c := range changed {
+		if _, err := stmt.Exec(c.After, c.ID); err != nil {
+			return nil, fmt.Errorf("error updating command %d: %w", c.ID, err)
+		}
+	}
+
+	return changed, tx.Commit()
+}
+
+// EachCommand calls fn for every command matching opts, oldest first, as
+// shell history files list them. Query is a substring match on the command;
+// a positive Limit keeps only the newest that many commands.
+func EachCommand(opts SearchOptions, fn func(Command) error) error {
+	if DB == nil {
+		return sql.ErrConnDone
+	}
+
+	where, args := opts.where("")
+	if q := strings.TrimSpace(opts.Query); q != "" {
+		where += ` AND command LIKE ? ESCAPE '\'`
+		args = append(args, "%"+escapeLike(q)+"%")
+	}
+
+	query := `SELECT ` + commandColumns("") + ` FROM commands WHERE ` + where + ` ORDER BY timestamp, id`
+	if opts.Limit > 0 {
+		query = `SELECT * FROM (SELECT ` + commandColumns("") + ` FROM commands WHERE ` + where + `
+			ORDER BY timestamp DESC, id DESC LIMIT ?) ORDER BY timestamp, id`
+		args = append(args, opts.Limit)
+	}
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c Command
+		var exitCode sql.NullString
+		if err := rows.Scan(&c.ID, &c.Command, &c.Directory, &exitCode, &c.Timestamp,
+			&c.StartTime, &c.EndTime, &c.DurationMs, &c.SessionID, &c.Source); err != nil {
+			return err
+		}
+		c.ExitCode = exitCode.String
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,160 @@
+package database
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPageCursorRoundTrip(t *testing.T) {
+	tests := []PageCursor{
+		{Timestamp: "2024-01-02 03:04:05", ID: 42},
+		{Timestamp: "", ID: 1},
+	}
+
+	for _, c := range tests {
+		got, err := DecodePageCursor(c.Encode())
+		if err != nil {
+			t.Errorf("DecodePageCursor(%+v.Encode()): %v", c, err)
+			continue
+		}
+		if got != c {
+			t.Errorf("cursor %+v came back as %+v", c, got)
+		}
+	}
+}
+
+func TestDecodePageCursorInvalid(t *testing.T) {
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "!!!"},
+		{"empty", ""},
+		{"no separator", encode("2024-01-02 03:04:05")},
+		{"id not a number", encode("2024-01-02 03:04:05|x")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if c, err := DecodePageCursor(tt.cursor); err == nil {
+				t.Errorf("DecodePageCursor(%q) = %+v, want an error", tt.cursor, c)
+			}
+		})
+	}
+}
+
+// insertAt logs command at the given time and returns its id.
+func insertAt(t *testing.T, command, dir string, at time.Time) int {
+	t.Helper()
+	id, err := InsertCommand(LogEntry{Command: command, Directory: dir, ExitCode: "0", DurationMs: -1, LoggedAt: at})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestListCommandsPagination(t *testing.T) {
+	migrateTestDB(t)
+
+	// Several commands share a timestamp, so the id has to break ties
+	base := time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local)
+	for i := range 7 {
+		insertAt(t, fmt.Sprintf("cmd %d", i), "/work", base.Add(time.Duration(i/3)*time.Second))
+	}
+
+	tests := []struct {
+		name      string
+		opts      SearchOptions
+		wantTotal int
+		wantPages [][]string
+	}{
+		{
+			name:      "pages of three",
+			opts:      SearchOptions{Limit: 3},
+			wantTotal: 7,
+			wantPages: [][]string{{"cmd 6", "cmd 5", "cmd 4"}, {"cmd 3", "cmd 2", "cmd 1"}, {"cmd 0"}},
+		},
+		{
+			name:      "page size divides the total",
+			opts:      SearchOptions{Limit: 7},
+			wantTotal: 7,
+			wantPages: [][]string{{"cmd 6", "cmd 5", "cmd 4", "cmd 3", "cmd 2", "cmd 1", "cmd 0"}},
+		},
+		{
+			name:      "filtered",
+			opts:      SearchOptions{Query: "cmd 1", Limit: 1},
+			wantTotal: 1,
+			wantPages: [][]string{{"cmd 1"}},
+		},
+		{
+			name:      "nothing matches",
+			opts:      SearchOptions{Folder: "/elsewhere"},
+			wantTotal: 0,
+			wantPages: [][]string{nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var after *PageCursor
+			for i, want := range tt.wantPages {
+				commands, total, next, err := ListCommands(tt.opts, after)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if total != tt.wantTotal {
+					t.Errorf("page %d: total %d, want %d", i, total, tt.wantTotal)
+				}
+
+				var got []string
+				for _, c := range commands {
+					got = append(got, c.Command)
+				}
+				if fmt.Sprint(got) != fmt.Sprint(want) {
+					t.Errorf("page %d = %q, want %q", i, got, want)
+				}
+
+				last := i == len(tt.wantPages)-1
+				if last != (next == nil) {
+					t.Fatalf("page %d: next cursor %v, last page %v", i, next, last)
+				}
+				after = next
+			}
+		})
+	}
+}
+
+func TestListCommandsStableWhileInserting(t *testing.T) {
+	migrateTestDB(t)
+
+	base := time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local)
+	for i := range 4 {
+		insertAt(t, fmt.Sprintf("cmd %d", i), "/work", base.Add(time.Duration(i)*time.Second))
+	}
+
+	first, _, next, err := ListCommands(SearchOptions{Limit: 2}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 2 || next == nil {
+		t.Fatalf("first page = %d commands, next %v", len(first), next)
+	}
+
+	// A newer command must not push rows from the first page onto the second
+	insertAt(t, "newer", "/work", base.Add(time.Minute))
+
+	second, total, _, err := ListCommands(SearchOptions{Limit: 2}, next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 5 {
+		t.Errorf("total %d, want 5", total)
+	}
+	if len(second) != 2 || second[0].Command != "cmd 1" || second[1].Command != "cmd 0" {
+		t.Errorf("second page = %+v, want cmd 1 and cmd 0", second)
+	}
+}
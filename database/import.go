@@ -0,0 +1,111 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ImportResult counts what ImportCommands did with its entries.
+type ImportResult struct {
+	Imported   int
+	Duplicates int
+}
+
+// ImportCommands stores commands read from a shell history file, marking
+// them with source. Entries without a start time get the undated timestamp.
+//
+// An entry is a duplicate, and skipped, if the database already held the
+// same command at the same time (logged or started that second) before the
+// import; an undated entry is a duplicate if the same command was already
+// imported from the same source. Repeats within the file are kept, so
+// frequencies survive and importing a file twice is harmless.
+func ImportCommands(entries []LogEntry, source string, undated time.Time) (ImportResult, error) {
+	var result ImportResult
+	if DB == nil {
+		return result, sql.ErrConnDone
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+
+	dated, undatedSeen, err := existingImportKeys(tx, source)
+	if err != nil {
+		return result, err
+	}
+
+	stmt, err := tx.Prepare(`INSERT INTO commands(command, exit_code, directory, timestamp, start_time, end_time, duration_ms, source)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return result, err
+	}
+	defer stmt.Close()
+
+	for _, entry := range entries {
+		var start, end sql.NullString
+		var duration sql.NullInt64
+		timestamp := undated.Format(TimestampFormat)
+
+		if entry.StartTime.IsZero() {
+			if undatedSeen[entry.Command] {
+				result.Duplicates++
+				continue
+			}
+		} else {
+			endTime := entry.StartTime
+			start = sql.NullString{String: entry.StartTime.Format(StartTimeFormat), Valid: true}
+			if entry.DurationMs >= 0 {
+				duration = sql.NullInt64{Int64: entry.DurationMs, Valid: true}
+				endTime = entry.StartTime.Add(time.Duration(entry.DurationMs) * time.Millisecond)
+				end = sql.NullString{String: endTime.Format(StartTimeFormat), Valid: true}
+			}
+			timestamp = endTime.Format(TimestampFormat)
+
+			if dated[entry.Command+"\x00"+entry.StartTime.Format(TimestampFormat)] || dated[entry.Command+"\x00"+timestamp] {
+				result.Duplicates++
+				continue
+			}
+		}
+
+		exitCode := sql.NullString{String: entry.ExitCode, Valid: entry.ExitCode != ""}
+		if _, err := stmt.Exec(entry.Command, exitCode, entry.Directory, timestamp, start, end, duration, source); err != nil {
+			return result, err
+		}
+		result.Imported++
+	}
+
+	return result, tx.Commit()
+}
+
+// existingImportKeys snapshots what is already stored: "command\x00second"
+// keys for both the logged and start time of every command, and the
+// commands previously imported from source.
+func existingImportKeys(tx *sql.Tx, source string) (map[string]bool, map[string]bool, error) {
+	dated := make(map[string]bool)
+	undated := make(map[string]bool)
+
+	rows, err := tx.Query(`SELECT command, timestamp, start_time, source FROM commands`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var command, timestamp string
+		var start, rowSource sql.NullString
+		if err := rows.Scan(&command, &timestamp, &start, &rowSource); err != nil {
+			return nil, nil, err
+		}
+
+		dated[command+"\x00"+timestamp] = true
+		if len(start.String) >= len(TimestampFormat) {
+			dated[command+"\x00"+start.String[:len(TimestampFormat)]] = true
+		}
+		if rowSource.String == source {
+			undated[command] = true
+		}
+	}
+	return dated, undated, rows.Err()
+}
@@ -0,0 +1,277 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// A migration moves the schema from version-1 to version. Migrations are
+// applied in order and never edited once released; schema changes go in a
+// new entry at the end of the list.
+type migration struct {
+	version     int
+	description string
+	up          func(tx *sql.Tx) error
+}
+
+var migrations = []migration{
+	{
+		version:     1,
+		description: "create commands table",
+		up: execStatements(`
+			CREATE TABLE IF NOT EXISTS commands (
+			       id INTEGER PRIMARY KEY,
+			       command TEXT,
+			       directory TEXT,
+			       exit_code INTEGER,
+			       timestamp TEXT
+			)`),
+	},
+	{
+		version:     2,
+		description: "add start_time, end_time and duration_ms to commands",
+		// Databases touched by the pre-migration release may already have these
+		up: func(tx *sql.Tx) error {
+			for _, column := range []struct{ name, definition string }{
+				{"start_time", "TEXT"},
+				{"end_time", "TEXT"},
+				{"duration_ms", "INTEGER"},
+			} {
+				if err := addColumnIfMissing(tx, "commands", column.name, column.definition); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		version:     3,
+		description: "index commands by timestamp for paginated listing",
+		up: execStatements(
+			`CREATE INDEX IF NOT EXISTS idx_commands_timestamp_id ON commands(timestamp DESC, id DESC)`,
+		),
+	},
+	{
+		version:     4,
+		description: "add sessions table and commands.session_id",
+		up: func(tx *sql.Tx) error {
+			err := execStatements(`
+				CREATE TABLE IF NOT EXISTS sessions (
+				       id TEXT PRIMARY KEY,
+				       hostname TEXT,
+				       user TEXT,
+				       tty TEXT,
+				       shell TEXT,
+				       started_at TEXT
+				)`)(tx)
+			if err != nil {
+				return err
+			}
+			if err := addColumnIfMissing(tx, "commands", "session_id", "TEXT"); err != nil {
+				return err
+			}
+			return execStatements(`CREATE INDEX IF NOT EXISTS idx_commands_session_id ON commands(session_id)`)(tx)
+		},
+	},
+	{
+		version:     5,
+		description: "add commands.source to mark imported history",
+		up: func(tx *sql.Tx) error {
+			return addColumnIfMissing(tx, "commands", "source", "TEXT")
+		},
+	},
+	{
+		version:     6,
+		description: "add commands_fts full-text index with sync triggers",
+		up: execStatements(
+			`CREATE VIRTUAL TABLE commands_fts USING fts5(
+			       command, content = 'commands', content_rowid = 'id', tokenize = 'unicode61'
+			)`,
+			`CREATE TRIGGER commands_fts_insert AFTER INSERT ON commands BEGIN
+			       INSERT INTO commands_fts(rowid, command) VALUES (new.id, new.command);
+			END`,
+			`CREATE TRIGGER commands_fts_delete AFTER DELETE ON commands BEGIN
+			       INSERT INTO commands_fts(commands_fts, rowid, command) VALUES ('delete', old.id, old.command);
+			END`,
+			`CREATE TRIGGER commands_fts_update AFTER UPDATE OF command ON commands BEGIN
+			       INSERT INTO commands_fts(commands_fts, rowid, command) VALUES ('delete', old.id, old.command);
+			       INSERT INTO commands_fts(rowid, command) VALUES (new.id, new.command);
+			END`,
+			`INSERT INTO commands_fts(commands_fts) VALUES ('rebuild')`,
+		),
+	},
+}
+
+// MigrationStatus describes one known migration and whether the open
+// database has it.
+type MigrationStatus struct {
+	Version     int
+	Description string
+	Applied     bool
+	AppliedAt   string
+}
+
+// LatestSchemaVersion is the schema version this build of cmdo expects.
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+func execStatements(statements ...string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		for _, stmt := range statements {
+			if _, err := tx.Exec(stmt); err != nil {
+				return fmt.Errorf("%w: %s", err, stmt)
+			}
+		}
+		return nil
+	}
+}
+
+func ensureSchemaVersionTable() error {
+	_, err := DB.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_version (
+	       version INTEGER PRIMARY KEY,
+	       description TEXT,
+	       applied_at TEXT
+	);`)
+	return err
+}
+
+// SchemaVersion returns the highest migration applied to the open database,
+// or 0 for a database that has never been migrated.
+func SchemaVersion() (int, error) {
+	if err := ensureSchemaVersionTable(); err != nil {
+		return 0, err
+	}
+
+	var version sql.NullInt64
+	if err := DB.QueryRow("SELECT MAX(version) FROM schema_version").Scan(&version); err != nil {
+		return 0, err
+	}
+	return int(version.Int64), nil
+}
+
+// Migrate applies every pending migration in a single transaction, so a
+// failure leaves the database exactly as it was. It returns the migrations
+// that were applied.
+func Migrate() ([]MigrationStatus, error) {
+	current, err := SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	if current > LatestSchemaVersion() {
+		return nil, fmt.Errorf("database schema version %d is newer than this cmdo supports (%d), please upgrade cmdo", current, LatestSchemaVersion())
+	}
+	if current == LatestSchemaVersion() {
+		return nil, nil
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var applied []MigrationStatus
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+
+		if err := m.up(tx); err != nil {
+			return nil, fmt.Errorf("migration %d (%s): %w", m.version, m.description, err)
+		}
+
+		appliedAt := time.Now().Format(TimestampFormat)
+		_, err := tx.Exec("INSERT INTO schema_version(version, description, applied_at) VALUES(?, ?, ?)",
+			m.version, m.description, appliedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		applied = append(applied, MigrationStatus{
+			Version:     m.version,
+			Description: m.description,
+			Applied:     true,
+			AppliedAt:   appliedAt,
+		})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// GetMigrationStatus lists every known migration alongside whether it has
+// been applied to the open database.
+func GetMigrationStatus() ([]MigrationStatus, error) {
+	if err := ensureSchemaVersionTable(); err != nil {
+		return nil, err
+	}
+
+	rows, err := DB.Query("SELECT version, applied_at FROM schema_version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	appliedAt := make(map[int]string)
+	for rows.Next() {
+		var version int
+		var at sql.NullString
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		appliedAt[version] = at.String
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	for _, m := range migrations {
+		at, ok := appliedAt[m.version]
+		statuses = append(statuses, MigrationStatus{
+			Version:     m.version,
+			Description: m.description,
+			Applied:     ok,
+			AppliedAt:   at,
+		})
+	}
+	return statuses, nil
+}
+
+func addColumnIfMissing(tx *sql.Tx, table, column, definition string) error {
+	rows, err := tx.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// openTestDB opens an empty database in a temporary directory as DB and
+// closes it when the test ends.
+func openTestDB(t *testing.T) {
+	t.Helper()
+	if err := OpenDB(filepath.Join(t.TempDir(), "cmdo.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		DB.Close()
+		DB = nil
+	})
+}
+
+// migrateTestDB is openTestDB with the schema brought up to date.
+func migrateTestDB(t *testing.T) {
+	t.Helper()
+	openTestDB(t)
+	if _, err := Migrate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrationVersions(t *testing.T) {
+	for i, m := range migrations {
+		if m.version != i+1 {
+			t.Errorf("migration %d has version %d; versions must count up from 1", i, m.version)
+		}
+		if m.description == "" {
+			t.Errorf("migration %d has no description", m.version)
+		}
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup prepares the database before Migrate runs
+		setup       []string
+		wantApplied int
+	}{
+		{
+			name:        "empty database",
+			wantApplied: len(migrations),
+		},
+		{
+			name: "database from before migrations",
+			setup: []string{
+				`CREATE TABLE commands (id INTEGER PRIMARY KEY, command TEXT, directory TEXT, exit_code INTEGER, timestamp TEXT, start_time TEXT)`,
+				`INSERT INTO commands(command, directory, exit_code, timestamp) VALUES('make test', '/work', 0, '2024-01-02 03:04:05')`,
+			},
+			wantApplied: len(migrations),
+		},
+		{
+			name: "partly migrated database",
+			setup: []string{
+				`CREATE TABLE schema_version (version INTEGER PRIMARY KEY, description TEXT, applied_at TEXT)`,
+				`CREATE TABLE commands (id INTEGER PRIMARY KEY, command TEXT, directory TEXT, exit_code INTEGER, timestamp TEXT,
+					start_time TEXT, end_time TEXT, duration_ms INTEGER)`,
+				`INSERT INTO schema_version(version) VALUES(1), (2)`,
+			},
+			wantApplied: len(migrations) - 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			openTestDB(t)
+			for _, stmt := range tt.setup {
+				if _, err := DB.Exec(stmt); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			applied, err := Migrate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(applied) != tt.wantApplied {
+				t.Errorf("applied %d migrations, want %d", len(applied), tt.wantApplied)
+			}
+
+			version, err := SchemaVersion()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if version != LatestSchemaVersion() {
+				t.Errorf("schema version %d, want %d", version, LatestSchemaVersion())
+			}
+
+			// A second run has nothing left to do
+			applied, err = Migrate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(applied) != 0 {
+				t.Errorf("second Migrate applied %d migrations", len(applied))
+			}
+
+			statuses, err := GetMigrationStatus()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range statuses {
+				if !s.Applied {
+					t.Errorf("migration %d not reported as applied", s.Version)
+				}
+			}
+		})
+	}
+}
+
+func TestMigrateNewerSchema(t *testing.T) {
+	migrateTestDB(t)
+	if _, err := DB.Exec("INSERT INTO schema_version(version) VALUES(?)", LatestSchemaVersion()+1); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Migrate(); err == nil {
+		t.Error("Migrate accepted a schema newer than this build")
+	}
+}
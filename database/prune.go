@@ -0,0 +1,87 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// PruneOptions selects commands to delete. Only commands matching Filter are
+// considered (its Query matches like in ListCommands); of those, the
+// ones logged before OlderThan or outside the newest KeepLast go. Zero values
+// disable the age and count limits, leaving every command that matches
+// Filter.
+type PruneOptions struct {
+	Filter    SearchOptions
+	OlderThan time.Time
+	KeepLast  int
+}
+
+// PruneCommands deletes the commands selected by opts and returns how many
+// there were; with dryRun it only counts them. Sessions left without
+// commands are deleted too.
+func PruneCommands(opts PruneOptions, dryRun bool) (int, error) {
+	if DB == nil {
+		return 0, sql.ErrConnDone
+	}
+
+	filterWhere, filterArgs := opts.Filter.where("")
+	if q := strings.TrimSpace(opts.Filter.Query); q != "" {
+		filterWhere += ` AND (command LIKE ? ESCAPE '\' OR directory LIKE ? ESCAPE '\')`
+		pattern := "%" + escapeLike(q) + "%"
+		filterArgs = append(filterArgs, pattern, pattern)
+	}
+	where, args := filterWhere, append([]any{}, filterArgs...)
+
+	var limits []string
+	if !opts.OlderThan.IsZero() {
+		limits = append(limits, "timestamp < ?")
+		args = append(args, opts.OlderThan.Format(TimestampFormat))
+	}
+	if opts.KeepLast > 0 {
+		limits = append(limits, `id NOT IN (SELECT id FROM commands WHERE `+filterWhere+`
+			ORDER BY timestamp DESC, id DESC LIMIT ?)`)
+		args = append(args, filterArgs...)
+		args = append(args, opts.KeepLast)
+	}
+	if len(limits) > 0 {
+		where += " AND (" + strings.Join(limits, " OR ") + ")"
+	}
+
+	if dryRun {
+		var count int
+		err := DB.QueryRow("SELECT COUNT(*) FROM commands WHERE "+where, args...).Scan(&count)
+		return count, err
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM commands WHERE "+where, args...)
+	if err != nil {
+		return 0, err
+	}
+	removed, _ := result.RowsAffected()
+
+	if removed > 0 {
+		if _, err := tx.Exec(`DELETE FROM sessions WHERE id NOT IN (
+			SELECT session_id FROM commands WHERE session_id IS NOT NULL)`); err != nil {
+			return 0, err
+		}
+	}
+
+	return int(removed), tx.Commit()
+}
+
+// Vacuum rebuilds the database file to give the space of deleted rows back
+// to the file system.
+func Vacuum() error {
+	if DB == nil {
+		return sql.ErrConnDone
+	}
+	_, err := DB.Exec("VACUUM")
+	return err
+}
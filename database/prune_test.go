@@ -0,0 +1,134 @@
+package database
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPruneCommands(t *testing.T) {
+	base := time.Date(2024, 1, 10, 12, 0, 0, 0, time.Local)
+	// cmd 0 is the oldest, one day apart; odd ones ran in /b and failed
+	seed := func(t *testing.T) {
+		migrateTestDB(t)
+		for i := range 6 {
+			dir, exitCode := "/a", "0"
+			if i%2 == 1 {
+				dir, exitCode = "/b", "1"
+			}
+			_, err := InsertCommand(LogEntry{
+				Command:    fmt.Sprintf("cmd %d", i),
+				Directory:  dir,
+				ExitCode:   exitCode,
+				DurationMs: -1,
+				LoggedAt:   base.AddDate(0, 0, i),
+				Session:    Session{ID: fmt.Sprintf("session-%d", i)},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	one := 1
+	tests := []struct {
+		name     string
+		opts     PruneOptions
+		wantLeft []string
+	}{
+		{
+			name:     "older than",
+			opts:     PruneOptions{OlderThan: base.AddDate(0, 0, 2)},
+			wantLeft: []string{"cmd 2", "cmd 3", "cmd 4", "cmd 5"},
+		},
+		{
+			name:     "keep last",
+			opts:     PruneOptions{KeepLast: 2},
+			wantLeft: []string{"cmd 4", "cmd 5"},
+		},
+		{
+			name:     "either limit deletes",
+			opts:     PruneOptions{OlderThan: base.AddDate(0, 0, 1), KeepLast: 4},
+			wantLeft: []string{"cmd 2", "cmd 3", "cmd 4", "cmd 5"},
+		},
+		{
+			name:     "keep last counts only the filtered commands",
+			opts:     PruneOptions{Filter: SearchOptions{Folder: "/b"}, KeepLast: 1},
+			wantLeft: []string{"cmd 0", "cmd 2", "cmd 4", "cmd 5"},
+		},
+		{
+			name:     "filter with age",
+			opts:     PruneOptions{Filter: SearchOptions{ExitCode: &one}, OlderThan: base.AddDate(0, 0, 4)},
+			wantLeft: []string{"cmd 0", "cmd 2", "cmd 4", "cmd 5"},
+		},
+		{
+			name:     "filter alone deletes every match",
+			opts:     PruneOptions{Filter: SearchOptions{Dir: "/a"}},
+			wantLeft: []string{"cmd 1", "cmd 3", "cmd 5"},
+		},
+		{
+			name:     "keep more than there are",
+			opts:     PruneOptions{KeepLast: 10},
+			wantLeft: []string{"cmd 0", "cmd 1", "cmd 2", "cmd 3", "cmd 4", "cmd 5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seed(t)
+			wantRemoved := 6 - len(tt.wantLeft)
+
+			counted, err := PruneCommands(tt.opts, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if counted != wantRemoved {
+				t.Errorf("dry run counted %d, want %d", counted, wantRemoved)
+			}
+			if left := remainingCommands(t); len(left) != 6 {
+				t.Fatalf("dry run deleted commands, %d left", len(left))
+			}
+
+			removed, err := PruneCommands(tt.opts, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if removed != wantRemoved {
+				t.Errorf("removed %d, want %d", removed, wantRemoved)
+			}
+			if left := remainingCommands(t); !slices.Equal(left, tt.wantLeft) {
+				t.Errorf("left %q, want %q", left, tt.wantLeft)
+			}
+
+			// Every command had its own session, so sessions follow commands
+			var sessions int
+			if err := DB.QueryRow("SELECT COUNT(*) FROM sessions").Scan(&sessions); err != nil {
+				t.Fatal(err)
+			}
+			if sessions != len(tt.wantLeft) {
+				t.Errorf("%d sessions left, want %d", sessions, len(tt.wantLeft))
+			}
+		})
+	}
+}
+
+// remainingCommands returns the command text of every row, oldest first.
+func remainingCommands(t *testing.T) []string {
+	t.Helper()
+	rows, err := DB.Query("SELECT command FROM commands ORDER BY timestamp, id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var commands []string
+	for rows.Next() {
+		var c string
+		if err := rows.Scan(&c); err != nil {
+			t.Fatal(err)
+		}
+		commands = append(commands, c)
+	}
+	return commands
+}
@@ -9,7 +9,8 @@ import (
 	"strconv"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/tanu2534/cmdo/config"
+	_ "modernc.org/sqlite"
 )
 
 var DB *sql.DB
@@ -17,18 +18,54 @@ var dbPath string
 
 func GetGlobalDBPath() string {
 	if dbPath == "" {
-		homeDir, _ := os.UserHomeDir()
-		dbPath = filepath.Join(homeDir, ".cmdo", "cmdo.db")
+		dbPath = config.Get().DBPath
 	}
 	return dbPath
 }
 
+// TimestampFormat is the layout of the timestamp column, which records when
+// the command was logged. StartTimeFormat adds milliseconds for start_time and
+// end_time so short commands still get a meaningful duration.
+const (
+	TimestampFormat = "2006-01-02 15:04:05"
+	StartTimeFormat = "2006-01-02 15:04:05.000"
+)
+
+// Command is a stored command. ExitCode is empty when unknown, as for
+// imported history.
 type Command struct {
-	ID        int
+	ID         int
+	Command    string
+	Directory  string
+	ExitCode   string
+	Timestamp  string
+	StartTime  sql.NullString
+	EndTime    sql.NullString
+	DurationMs sql.NullInt64
+	SessionID  sql.NullString
+	// Source is NULL for commands logged by the shell hooks and names the
+	// origin of anything else, e.g. "import:zsh".
+	Source sql.NullString
+}
+
+// LogEntry is a finished command as reported by a shell hook. An empty
+// ExitCode means unknown and is stored as NULL.
+type LogEntry struct {
 	Command   string
-	Directory string
 	ExitCode  string
-	Timestamp string
+	Directory string
+	// StartTime is zero and DurationMs negative when the hook couldn't
+	// measure them; those columns are then left NULL.
+	StartTime  time.Time
+	DurationMs int64
+	// Session describes the shell the command ran in; it is not recorded
+	// when Session.ID is empty.
+	Session Session
+	// LoggedAt is when the hook reported the command; zero means now.
+	LoggedAt time.Time
+	// Source is empty for the shell hooks, or names what else logged the
+	// command (e.g. "api").
+	Source string
 }
 
 func DeleteCommand(id string) error {
@@ -74,10 +111,12 @@ func GetCommandsGrouped() (map[string][]Command, error) {
 
 	for rows.Next() {
 		var c Command
-		if err := rows.Scan(&c.ID, &c.Command, &c.Directory, &c.ExitCode, &c.Timestamp); err != nil {
+		var exitCode sql.NullString
+		if err := rows.Scan(&c.ID, &c.Command, &c.Directory, &exitCode, &c.Timestamp); err != nil {
 			log.Println("Error scanning row:", err)
 			continue
 		}
+		c.ExitCode = exitCode.String
 		grouped[c.Directory] = append(grouped[c.Directory], c)
 	}
 
@@ -85,53 +124,127 @@ func GetCommandsGrouped() (map[string][]Command, error) {
 	return grouped, nil
 }
 
+// InitDB opens the database at path and brings its schema up to date.
 func InitDB(path string) {
+	if err := OpenDB(path); err != nil {
+		log.Fatal(err)
+	}
+
+	if _, err := Migrate(); err != nil {
+		log.Fatalf("Error migrating database: %v", err)
+	}
+}
+
+// OpenDB opens the database at path without touching its schema, for callers
+// such as `cmdo db migrate --status` that need to inspect it first.
+func OpenDB(path string) error {
 	dbPath = path
 
 	// ✅ Create directory if it doesn't exist
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
-		log.Fatalf("Error creating database directory: %v", err)
+		return fmt.Errorf("error creating database directory: %w", err)
 	}
 
 	var err error
-	DB, err = sql.Open("sqlite3", path)
+	// Wait for the hooks, the daemon and the server instead of failing
+	// with "database is locked" when they write at the same time
+	DB, err = sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)")
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	// Test connection
 	if err = DB.Ping(); err != nil {
-		log.Fatalf("Error connecting to database: %v", err)
+		return fmt.Errorf("error connecting to database: %w", err)
 	}
 
-	sqlStmt := `
-	CREATE TABLE IF NOT EXISTS commands  (
-	       id INTEGER PRIMARY KEY,
-	       command TEXT,
-	       directory TEXT,
-	       exit_code INTEGER,
-	       timestamp TEXT
-	);`
+	return nil
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
 
-	_, err = DB.Exec(sqlStmt)
+// InsertCmd stores a finished command, registering its session on first use.
+func InsertCmd(entry LogEntry) error {
+	_, err := InsertCommand(entry)
+	return err
+}
+
+// InsertCommand is InsertCmd for callers that need the id of the new row.
+func InsertCommand(entry LogEntry) (int, error) {
+	if DB == nil {
+		return 0, sql.ErrConnDone
+	}
+	id, err := insertEntry(DB, entry)
+	return int(id), err
+}
+
+// InsertCmds stores several finished commands in one transaction, which is
+// much cheaper than one InsertCmd each.
+func InsertCmds(entries []LogEntry) error {
+	if DB == nil {
+		return sql.ErrConnDone
+	}
+
+	tx, err := DB.Begin()
 	if err != nil {
-		log.Fatalf("Error creating table: %q: %s\n", err, sqlStmt)
+		return err
 	}
+	defer tx.Rollback()
 
-	fmt.Println("DB Initialized")
+	for _, entry := range entries {
+		if _, err := insertEntry(tx, entry); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
-func InsertCmd(cmd string, code string, dir string) {
-	if DB == nil {
-		log.Fatal("DB is not initialized. Call InitDB first.")
+func insertEntry(db execer, entry LogEntry) (int64, error) {
+	loggedAt := entry.LoggedAt
+	if loggedAt.IsZero() {
+		loggedAt = time.Now()
+	}
+	timestamp := loggedAt.Format(TimestampFormat)
+
+	var start, end, sessionID, source sql.NullString
+	var duration sql.NullInt64
+	if !entry.StartTime.IsZero() {
+		start = sql.NullString{String: entry.StartTime.Format(StartTimeFormat), Valid: true}
+	}
+	if entry.DurationMs >= 0 {
+		duration = sql.NullInt64{Int64: entry.DurationMs, Valid: true}
+		if start.Valid {
+			endTime := entry.StartTime.Add(time.Duration(entry.DurationMs) * time.Millisecond)
+			end = sql.NullString{String: endTime.Format(StartTimeFormat), Valid: true}
+		}
+	}
+
+	if entry.Session.ID != "" {
+		sessionID = sql.NullString{String: entry.Session.ID, Valid: true}
+		session := entry.Session
+		// Without a start time from the hook the first command has to do
+		if session.StartedAt == "" {
+			session.StartedAt = timestamp
+		}
+		if err := registerSession(db, session); err != nil {
+			return 0, fmt.Errorf("error registering session: %w", err)
+		}
+	}
+	if entry.Source != "" {
+		source = sql.NullString{String: entry.Source, Valid: true}
 	}
 
-	sqlStmt := `INSERT INTO commands(command, exit_code, directory, timestamp) VALUES(?, ?, ?, ?)`
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
+	sqlStmt := `INSERT INTO commands(command, exit_code, directory, timestamp, start_time, end_time, duration_ms, session_id, source)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-	_, err := DB.Exec(sqlStmt, cmd, code, dir, timestamp)
+	exitCode := sql.NullString{String: entry.ExitCode, Valid: entry.ExitCode != ""}
+	result, err := db.Exec(sqlStmt, entry.Command, exitCode, entry.Directory, timestamp, start, end, duration, sessionID, source)
 	if err != nil {
-		log.Printf("Error inserting data: %s\n", err)
+		return 0, err
 	}
+	return result.LastInsertId()
 }
@@ -0,0 +1,191 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// SearchOptions filters commands for `cmdo search` and friends. Zero values
+// mean "no filter".
+type SearchOptions struct {
+	Query      string
+	Dir        string
+	Folder     string
+	Session    string
+	ExitCode   *int
+	FailedOnly bool
+	Since      time.Time
+	Until      time.Time
+	Limit      int
+}
+
+// where builds the WHERE clause (without the keyword) for every filter except
+// Query, qualifying columns with the given table alias.
+func (o SearchOptions) where(alias string) (string, []any) {
+	col := func(name string) string {
+		if alias == "" {
+			return name
+		}
+		return alias + "." + name
+	}
+
+	clauses := []string{"1 = 1"}
+	var args []any
+
+	if o.Dir != "" {
+		// Match the directory itself and anything below it
+		dir := strings.TrimRight(o.Dir, `/\`)
+		clauses = append(clauses, fmt.Sprintf("(%s = ? OR %s LIKE ? ESCAPE '\\' OR %s LIKE ? ESCAPE '\\')",
+			col("directory"), col("directory"), col("directory")))
+		escaped := escapeLike(dir)
+		args = append(args, dir, escaped+"/%", escaped+`\\%`)
+	}
+	if o.Folder != "" {
+		clauses = append(clauses, fmt.Sprintf("%s = ?", col("directory")))
+		args = append(args, o.Folder)
+	}
+	if o.Session != "" {
+		clauses = append(clauses, fmt.Sprintf("%s = ?", col("session_id")))
+		args = append(args, o.Session)
+	}
+	if o.ExitCode != nil {
+		clauses = append(clauses, fmt.Sprintf("%s = ?", col("exit_code")))
+		args = append(args, *o.ExitCode)
+	}
+	if o.FailedOnly {
+		clauses = append(clauses, fmt.Sprintf("%s != 0", col("exit_code")))
+	}
+	if !o.Since.IsZero() {
+		clauses = append(clauses, fmt.Sprintf("%s >= ?", col("timestamp")))
+		args = append(args, o.Since.Format(TimestampFormat))
+	}
+	if !o.Until.IsZero() {
+		clauses = append(clauses, fmt.Sprintf("%s <= ?", col("timestamp")))
+		args = append(args, o.Until.Format(TimestampFormat))
+	}
+
+	return strings.Join(clauses, " AND "), args
+}
+
+func escapeLike(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(s)
+}
+
+// SearchCommands returns commands matching opts, best match first. A query
+// is answered from the commands_fts index and ranked by bm25; without one
+// the newest commands come first. A query made only of punctuation, which
+// the index has no words for, falls back to a substring match.
+func SearchCommands(opts SearchOptions) ([]Command, error) {
+	if DB == nil {
+		return nil, sql.ErrConnDone
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = 20
+	}
+
+	if match := ftsQuery(opts.Query); match != "" {
+		return searchFTS(opts, match)
+	}
+	if strings.TrimSpace(opts.Query) != "" {
+		return searchLike(opts)
+	}
+	return searchRecent(opts)
+}
+
+func searchFTS(opts SearchOptions, match string) ([]Command, error) {
+	where, args := opts.where("c")
+	query := `SELECT ` + commandColumns("c") + `
+		FROM commands_fts f JOIN commands c ON c.id = f.rowid
+		WHERE commands_fts MATCH ? AND ` + where + `
+		ORDER BY bm25(commands_fts), c.timestamp DESC, c.id DESC
+		LIMIT ?`
+	args = append([]any{match}, args...)
+	args = append(args, opts.Limit)
+	return queryCommands(query, args...)
+}
+
+func searchLike(opts SearchOptions) ([]Command, error) {
+	where, args := opts.where("")
+	query := `SELECT ` + commandColumns("") + `
+		FROM commands WHERE ` + where + ` AND command LIKE ? ESCAPE '\'
+		ORDER BY timestamp DESC, id DESC LIMIT ?`
+	args = append(args, "%"+escapeLike(strings.TrimSpace(opts.Query))+"%", opts.Limit)
+	return queryCommands(query, args...)
+}
+
+func searchRecent(opts SearchOptions) ([]Command, error) {
+	where, args := opts.where("")
+	query := `SELECT ` + commandColumns("") + `
+		FROM commands WHERE ` + where + `
+		ORDER BY timestamp DESC, id DESC LIMIT ?`
+	args = append(args, opts.Limit)
+	return queryCommands(query, args...)
+}
+
+// commandColumns lists the columns queryCommands scans, optionally
+// qualified with a table alias.
+func commandColumns(alias string) string {
+	columns := []string{"id", "command", "directory", "exit_code", "timestamp", "start_time", "end_time", "duration_ms", "session_id", "source"}
+	if alias != "" {
+		for i, c := range columns {
+			columns[i] = alias + "." + c
+		}
+	}
+	return strings.Join(columns, ", ")
+}
+
+func queryCommands(query string, args ...any) ([]Command, error) {
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var commands []Command
+	for rows.Next() {
+		var c Command
+		var exitCode sql.NullString
+		if err := rows.Scan(&c.ID, &c.Command, &c.Directory, &exitCode, &c.Timestamp,
+			&c.StartTime, &c.EndTime, &c.DurationMs, &c.SessionID, &c.Source); err != nil {
+			return nil, err
+		}
+		c.ExitCode = exitCode.String
+		commands = append(commands, c)
+	}
+	return commands, rows.Err()
+}
+
+// ftsQuery turns free text into an FTS5 query: every word must appear, and
+// the last word may be a prefix so results show up while typing. Words are
+// quoted so characters like '-' or ':' aren't parsed as FTS5 syntax, and
+// words without a letter or digit are dropped because the tokenizer indexes
+// nothing for them. The result is empty when no word is left.
+func ftsQuery(q string) string {
+	var words []string
+	for _, w := range strings.Fields(q) {
+		if strings.IndexFunc(w, isTokenChar) >= 0 {
+			words = append(words, `"`+strings.ReplaceAll(w, `"`, `""`)+`"`)
+		}
+	}
+	if len(words) > 0 {
+		words[len(words)-1] += "*"
+	}
+	return strings.Join(words, " ")
+}
+
+func isTokenChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r)
+}
+
+// RecentCommands returns up to limit commands, most recent first.
+func RecentCommands(limit int) ([]Command, error) {
+	if DB == nil {
+		return nil, sql.ErrConnDone
+	}
+	return queryCommands(`SELECT `+commandColumns("")+`
+		FROM commands ORDER BY timestamp DESC, id DESC LIMIT ?`, limit)
+}
@@ -0,0 +1,191 @@
+package database
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMigrateKeepsExistingCommands(t *testing.T) {
+	openTestDB(t)
+	_, err := DB.Exec(`CREATE TABLE commands (id INTEGER PRIMARY KEY, command TEXT, directory TEXT, exit_code INTEGER, timestamp TEXT);
+		INSERT INTO commands(command, directory, exit_code, timestamp) VALUES('git status', '/work', 0, '2024-01-02 03:04:05')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The full-text index is built from the rows already there
+	found, err := SearchCommands(SearchOptions{Query: "status"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].Command != "git status" {
+		t.Errorf("search after migrating found %+v", found)
+	}
+}
+
+func TestSearchIndexFollowsChanges(t *testing.T) {
+	migrateTestDB(t)
+
+	id, err := InsertCommand(LogEntry{Command: "docker compose up", Directory: "/work", ExitCode: "0", DurationMs: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := InsertCommand(LogEntry{Command: "kubectl get pods", Directory: "/work", ExitCode: "0", DurationMs: -1}); err != nil {
+		t.Fatal(err)
+	}
+
+	search := func(query string) int {
+		t.Helper()
+		found, err := SearchCommands(SearchOptions{Query: query})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(found)
+	}
+
+	if n := search("compose"); n != 1 {
+		t.Errorf("found %d commands for a new command, want 1", n)
+	}
+
+	text := "podman compose up"
+	if _, err := UpdateCommand(id, CommandUpdate{Command: &text}); err != nil {
+		t.Fatal(err)
+	}
+	if n := search("docker"); n != 0 {
+		t.Errorf("found %d commands for replaced text, want 0", n)
+	}
+	if n := search("podman"); n != 1 {
+		t.Errorf("found %d commands for updated text, want 1", n)
+	}
+
+	if _, err := DeleteCommands([]int{id}); err != nil {
+		t.Fatal(err)
+	}
+	if n := search("compose"); n != 0 {
+		t.Errorf("found %d commands after deleting, want 0", n)
+	}
+	if n := search("kube"); n != 1 {
+		t.Errorf("prefix search found %d commands, want 1", n)
+	}
+}
+
+func TestSearchCommands(t *testing.T) {
+	migrateTestDB(t)
+	for _, e := range []LogEntry{
+		{Command: "git status", Directory: "/work/app", ExitCode: "0"},
+		{Command: "git push origin main", Directory: "/work/app/sub", ExitCode: "1"},
+		{Command: "make test", Directory: "/work/lib", ExitCode: "2"},
+		{Command: "git-lfs pull", Directory: "/work/application", ExitCode: "0"},
+	} {
+		e.DurationMs = -1
+		if err := InsertCmd(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	zero := 0
+	tests := []struct {
+		name string
+		opts SearchOptions
+		want []string
+	}{
+		{"no query lists newest first", SearchOptions{}, []string{"git-lfs pull", "make test", "git push origin main", "git status"}},
+		{"shorter matches rank first", SearchOptions{Query: "git"}, []string{"git status", "git-lfs pull", "git push origin main"}},
+		{"prefix of last word", SearchOptions{Query: "git pu"}, []string{"git-lfs pull", "git push origin main"}},
+		{"earlier words are whole", SearchOptions{Query: "orig main"}, nil},
+		{"every word must match", SearchOptions{Query: "git test"}, nil},
+		{"fts syntax is quoted", SearchOptions{Query: `git-lfs "pull`}, []string{"git-lfs pull"}},
+		{"punctuation only matches substrings", SearchOptions{Query: "-"}, []string{"git-lfs pull"}},
+		{"punctuation only with filter", SearchOptions{Query: " - ", FailedOnly: true}, nil},
+		{"punctuation words are dropped", SearchOptions{Query: "git -"}, []string{"git status", "git-lfs pull", "git push origin main"}},
+		{"dir includes subdirectories", SearchOptions{Dir: "/work/app"}, []string{"git push origin main", "git status"}},
+		{"folder is exact", SearchOptions{Folder: "/work/app"}, []string{"git status"}},
+		{"exit code", SearchOptions{ExitCode: &zero}, []string{"git-lfs pull", "git status"}},
+		{"failed only", SearchOptions{FailedOnly: true}, []string{"make test", "git push origin main"}},
+		{"query and filter", SearchOptions{Query: "git", FailedOnly: true}, []string{"git push origin main"}},
+		{"limit", SearchOptions{Limit: 1}, []string{"git-lfs pull"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := SearchCommands(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, c := range found {
+				got = append(got, c.Command)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFTSQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"git", `"git"*`},
+		{"git  push", `"git" "push"*`},
+		{`say "hi"`, `"say" """hi"""*`},
+		{"a:b -c", `"a:b" "-c"*`},
+		{"make && ls", `"make" "ls"*`},
+		{"- ||", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := ftsQuery(tt.query); got != tt.want {
+			t.Errorf("ftsQuery(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSearchUnknownExitCode(t *testing.T) {
+	migrateTestDB(t)
+	for _, e := range []LogEntry{
+		{Command: "make ok", ExitCode: "0"},
+		{Command: "make failed", ExitCode: "1"},
+		{Command: "make imported"},
+	} {
+		e.Directory = "/work"
+		e.DurationMs = -1
+		if err := InsertCmd(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	zero := 0
+	tests := []struct {
+		name string
+		opts SearchOptions
+		want []string
+	}{
+		{"unknown is listed", SearchOptions{}, []string{"make imported:", "make failed:1", "make ok:0"}},
+		{"unknown isn't a failure", SearchOptions{FailedOnly: true}, []string{"make failed:1"}},
+		{"unknown isn't a success", SearchOptions{ExitCode: &zero}, []string{"make ok:0"}},
+		{"unknown with a query", SearchOptions{Query: "imported"}, []string{"make imported:"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := SearchCommands(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, c := range found {
+				got = append(got, c.Command+":"+c.ExitCode)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
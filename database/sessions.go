@@ -0,0 +1,106 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Session is one interactive shell, identified by the ID its hook generated
+// at startup.
+type Session struct {
+	ID       string
+	Hostname string
+	User     string
+	TTY      string
+	Shell    string
+	// StartedAt is when the shell started, as reported by its hook. Hooks
+	// older than v3 don't report it, so their sessions start at the first
+	// command instead.
+	StartedAt string
+	// Filled in by ListSessions and GetSession
+	CommandCount int
+	LastActivity string
+}
+
+func registerSession(db execer, s Session) error {
+	_, err := db.Exec(`INSERT INTO sessions(id, hostname, user, tty, shell, started_at)
+		VALUES(?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO NOTHING`,
+		s.ID, s.Hostname, s.User, s.TTY, s.Shell, s.StartedAt)
+	return err
+}
+
+const sessionSelect = `SELECT s.id, IFNULL(s.hostname, ''), IFNULL(s.user, ''), IFNULL(s.tty, ''),
+	IFNULL(s.shell, ''), IFNULL(s.started_at, ''), COUNT(c.id), IFNULL(MAX(c.timestamp), '')
+	FROM sessions s LEFT JOIN commands c ON c.session_id = s.id`
+
+func scanSessions(rows *sql.Rows) ([]Session, error) {
+	defer rows.Close()
+
+	var sessions []Session
+	for rows.Next() {
+		var s Session
+		if err := rows.Scan(&s.ID, &s.Hostname, &s.User, &s.TTY, &s.Shell, &s.StartedAt,
+			&s.CommandCount, &s.LastActivity); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// ListSessions returns up to limit sessions, most recently active first.
+func ListSessions(limit int) ([]Session, error) {
+	if DB == nil {
+		return nil, sql.ErrConnDone
+	}
+
+	rows, err := DB.Query(sessionSelect+`
+		GROUP BY s.id
+		ORDER BY IFNULL(MAX(c.timestamp), s.started_at) DESC
+		LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanSessions(rows)
+}
+
+// GetSession finds a session by its ID or a unique prefix of it, the way git
+// accepts abbreviated commit hashes.
+func GetSession(idOrPrefix string) (Session, error) {
+	if DB == nil {
+		return Session{}, sql.ErrConnDone
+	}
+
+	rows, err := DB.Query(sessionSelect+`
+		WHERE s.id = ? OR s.id LIKE ? ESCAPE '\'
+		GROUP BY s.id
+		LIMIT 2`, idOrPrefix, escapeLike(idOrPrefix)+"%")
+	if err != nil {
+		return Session{}, err
+	}
+	sessions, err := scanSessions(rows)
+	if err != nil {
+		return Session{}, err
+	}
+
+	switch {
+	case len(sessions) == 0:
+		return Session{}, fmt.Errorf("no session matches %q", idOrPrefix)
+	case len(sessions) > 1 && sessions[0].ID != idOrPrefix && sessions[1].ID != idOrPrefix:
+		return Session{}, fmt.Errorf("session prefix %q is ambiguous", idOrPrefix)
+	case len(sessions) > 1 && sessions[1].ID == idOrPrefix:
+		return sessions[1], nil
+	}
+	return sessions[0], nil
+}
+
+// SessionCommands returns every command of a session in the order it ran.
+func SessionCommands(id string) ([]Command, error) {
+	if DB == nil {
+		return nil, sql.ErrConnDone
+	}
+	return queryCommands(`SELECT `+commandColumns("")+`
+		FROM commands WHERE session_id = ?
+		ORDER BY timestamp, id`, id)
+}
@@ -0,0 +1,207 @@
+package database
+
+import (
+	"database/sql"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatsOptions limits which commands are counted. Zero values mean all
+// history, the top 10 executables/folders and the last 30 days.
+type StatsOptions struct {
+	Since time.Time
+	Top   int
+	Days  int
+}
+
+type ExecutableCount struct {
+	Executable string `json:"executable"`
+	Count      int    `json:"count"`
+	Failed     int    `json:"failed"`
+}
+
+type FolderStats struct {
+	Folder      string  `json:"folder"`
+	Total       int     `json:"total"`
+	Failed      int     `json:"failed"`
+	FailureRate float64 `json:"failureRate"`
+}
+
+type DayCount struct {
+	Day   string `json:"day"`
+	Count int    `json:"count"`
+}
+
+type Stats struct {
+	TotalCommands  int               `json:"totalCommands"`
+	FailedCommands int               `json:"failedCommands"`
+	TopCommands    []ExecutableCount `json:"topCommands"`
+	Folders        []FolderStats     `json:"folders"`
+	Hours          [24]int           `json:"hours"`
+	Days           []DayCount        `json:"days"`
+}
+
+// minFolderSample is how many commands a folder needs before its failure
+// rate is ranked with the others.
+const minFolderSample = 5
+
+// GetStats aggregates the command history. Rows are aggregated in Go rather
+// than SQL because grouping by executable needs NormalizeExecutable.
+func GetStats(opts StatsOptions) (Stats, error) {
+	var stats Stats
+	if DB == nil {
+		return stats, sql.ErrConnDone
+	}
+	if opts.Top <= 0 {
+		opts.Top = 10
+	}
+	if opts.Days <= 0 {
+		opts.Days = 30
+	}
+
+	query := "SELECT command, directory, exit_code, timestamp FROM commands"
+	var args []any
+	if !opts.Since.IsZero() {
+		query += " WHERE timestamp >= ?"
+		args = append(args, opts.Since.Format(TimestampFormat))
+	}
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return stats, err
+	}
+	defer rows.Close()
+
+	// Per-day counts cover the last opts.Days days, including empty ones
+	today := time.Now()
+	dayIndex := make(map[string]int)
+	for i := opts.Days - 1; i >= 0; i-- {
+		day := today.AddDate(0, 0, -i).Format("2006-01-02")
+		dayIndex[day] = len(stats.Days)
+		stats.Days = append(stats.Days, DayCount{Day: day})
+	}
+
+	executables := make(map[string]*ExecutableCount)
+	folders := make(map[string]*FolderStats)
+
+	for rows.Next() {
+		var command, directory, timestamp string
+		var exitCode sql.NullString
+		if err := rows.Scan(&command, &directory, &exitCode, &timestamp); err != nil {
+			return stats, err
+		}
+
+		// A missing or malformed exit code counts as unknown, not failed
+		code, err := strconv.Atoi(strings.TrimSpace(exitCode.String))
+		failed := exitCode.Valid && err == nil && code != 0
+		stats.TotalCommands++
+		if failed {
+			stats.FailedCommands++
+		}
+
+		if exe := NormalizeExecutable(command); exe != "" {
+			e, ok := executables[exe]
+			if !ok {
+				e = &ExecutableCount{Executable: exe}
+				executables[exe] = e
+			}
+			e.Count++
+			if failed {
+				e.Failed++
+			}
+		}
+
+		f, ok := folders[directory]
+		if !ok {
+			f = &FolderStats{Folder: directory}
+			folders[directory] = f
+		}
+		f.Total++
+		if failed {
+			f.Failed++
+		}
+
+		if t, err := time.ParseInLocation(TimestampFormat, timestamp, time.Local); err == nil {
+			stats.Hours[t.Hour()]++
+			if i, ok := dayIndex[t.Format("2006-01-02")]; ok {
+				stats.Days[i].Count++
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return stats, err
+	}
+
+	for _, e := range executables {
+		stats.TopCommands = append(stats.TopCommands, *e)
+	}
+	sort.Slice(stats.TopCommands, func(i, j int) bool {
+		a, b := stats.TopCommands[i], stats.TopCommands[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Executable < b.Executable
+	})
+	if len(stats.TopCommands) > opts.Top {
+		stats.TopCommands = stats.TopCommands[:opts.Top]
+	}
+
+	// Folders are ranked by failure rate, busiest first on ties. Folders with
+	// fewer than minFolderSample commands come last, so a single failed
+	// command doesn't top the list with a 100% failure rate
+	for _, f := range folders {
+		f.FailureRate = float64(f.Failed) / float64(f.Total)
+		stats.Folders = append(stats.Folders, *f)
+	}
+	sort.Slice(stats.Folders, func(i, j int) bool {
+		a, b := stats.Folders[i], stats.Folders[j]
+		if sampledA, sampledB := a.Total >= minFolderSample, b.Total >= minFolderSample; sampledA != sampledB {
+			return sampledA
+		}
+		if a.FailureRate != b.FailureRate {
+			return a.FailureRate > b.FailureRate
+		}
+		if a.Total != b.Total {
+			return a.Total > b.Total
+		}
+		return a.Folder < b.Folder
+	})
+	if len(stats.Folders) > opts.Top {
+		stats.Folders = stats.Folders[:opts.Top]
+	}
+
+	return stats, nil
+}
+
+// commandPrefixes run another command; stats count the command they run.
+var commandPrefixes = map[string]bool{
+	"sudo": true, "doas": true, "time": true, "nohup": true, "env": true,
+	"nice": true, "exec": true, "command": true, "builtin": true,
+}
+
+// NormalizeExecutable returns the program a command line runs: environment
+// assignments and wrappers like sudo are skipped, directories and Windows
+// extensions are stripped, so "sudo /usr/bin/apt update" and
+// "FOO=1 apt.exe install" both count as "apt".
+func NormalizeExecutable(command string) string {
+	for _, field := range strings.Fields(command) {
+		if strings.HasPrefix(field, "-") || (strings.Contains(field, "=") && !strings.ContainsAny(field, `/\`)) {
+			continue
+		}
+
+		exe := filepath.Base(strings.ReplaceAll(strings.Trim(field, `"'`), `\`, "/"))
+		exe = strings.ToLower(exe)
+		for _, ext := range []string{".exe", ".cmd", ".bat", ".ps1"} {
+			exe = strings.TrimSuffix(exe, ext)
+		}
+
+		if commandPrefixes[exe] {
+			continue
+		}
+		return exe
+	}
+	return ""
+}
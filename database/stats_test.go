@@ -0,0 +1,99 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalizeExecutable(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"git status", "git"},
+		{"sudo /usr/bin/apt update", "apt"},
+		{"FOO=1 apt.exe install", "apt"},
+		{"sudo -E env A=b nice make", "make"},
+		{`C:\Tools\git.exe log`, "git"},
+		{"./configure --prefix=/usr", "configure"},
+		{"Get-ChildItem.ps1", "get-childitem"},
+		{"A=1 B=2", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeExecutable(tt.command); got != tt.want {
+			t.Errorf("NormalizeExecutable(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestGetStats(t *testing.T) {
+	migrateTestDB(t)
+
+	now := time.Now()
+	rows := []struct {
+		command, dir string
+		// exitCode is stored as is, so it can be NULL or malformed
+		exitCode any
+	}{
+		{"make", "/busy", 1},
+		{"make", "/busy", 2},
+		{"make", "/busy", 0},
+		{"make", "/busy", 0},
+		{"make", "/busy", 0},
+		{"make", "/busy", 0},
+		{"make", "/flaky", 1},
+		{"make", "/flaky", 0},
+		{"make", "/flaky", 1},
+		{"make", "/flaky", 0},
+		{"make", "/flaky", 1},
+		{"ls", "/once", 1},
+		{"ls", "/clean", 0},
+		{"ls", "/clean", nil},
+		{"ls", "/clean", ""},
+		{"ls", "/clean", "oops"},
+	}
+	for _, r := range rows {
+		_, err := DB.Exec("INSERT INTO commands(command, directory, exit_code, timestamp) VALUES(?, ?, ?, ?)",
+			r.command, r.dir, r.exitCode, now.Format(TimestampFormat))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats, err := GetStats(StatsOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.TotalCommands != 16 || stats.FailedCommands != 6 {
+		t.Errorf("total %d, failed %d; want 16 and 6 (unknown exit codes aren't failures)", stats.TotalCommands, stats.FailedCommands)
+	}
+
+	// /flaky fails at a higher rate than /busy; /once and /clean have too
+	// few commands to rank ahead of them, even at a 100% failure rate
+	wantFolders := []FolderStats{
+		{Folder: "/flaky", Total: 5, Failed: 3, FailureRate: 0.6},
+		{Folder: "/busy", Total: 6, Failed: 2, FailureRate: 2.0 / 6},
+		{Folder: "/once", Total: 1, Failed: 1, FailureRate: 1},
+		{Folder: "/clean", Total: 4, Failed: 0, FailureRate: 0},
+	}
+	if len(stats.Folders) != len(wantFolders) {
+		t.Fatalf("folders = %+v", stats.Folders)
+	}
+	for i, want := range wantFolders {
+		if stats.Folders[i] != want {
+			t.Errorf("folder %d = %+v, want %+v", i, stats.Folders[i], want)
+		}
+	}
+
+	wantTop := []ExecutableCount{{Executable: "make", Count: 11, Failed: 5}, {Executable: "ls", Count: 5, Failed: 1}}
+	if len(stats.TopCommands) != 2 || stats.TopCommands[0] != wantTop[0] || stats.TopCommands[1] != wantTop[1] {
+		t.Errorf("top commands = %+v, want %+v", stats.TopCommands, wantTop)
+	}
+
+	if last := stats.Days[len(stats.Days)-1]; last.Count != 16 {
+		t.Errorf("today counted %d commands, want 16", last.Count)
+	}
+}
@@ -0,0 +1,312 @@
+// Package histfile reads and writes the history files of the shells cmdo
+// hooks into.
+package histfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry is one command in a shell history file. Shells record less than
+// cmdo does, so Time is zero and Duration negative when unknown.
+type Entry struct {
+	Command  string
+	Time     time.Time
+	Duration time.Duration
+}
+
+// Writer writes entries in one shell's history format.
+type Writer interface {
+	Write(e Entry) error
+	Flush() error
+}
+
+// Formats lists the shell formats NewWriter accepts.
+var Formats = []string{"bash_history", "zsh_extended", "fish"}
+
+// NewWriter returns a Writer for a format in Formats.
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	bw := bufio.NewWriter(w)
+	switch format {
+	case "bash_history":
+		return bashWriter{bw}, nil
+	case "zsh_extended":
+		return zshWriter{bw}, nil
+	case "fish":
+		return fishWriter{bw}, nil
+	}
+	return nil, fmt.Errorf("unknown history format %q", format)
+}
+
+// bashWriter writes the format bash uses with HISTTIMEFORMAT set: a
+// "#<epoch>" comment before each command.
+type bashWriter struct{ w *bufio.Writer }
+
+func (b bashWriter) Write(e Entry) error {
+	if !e.Time.IsZero() {
+		fmt.Fprintf(b.w, "#%d\n", e.Time.Unix())
+	}
+	_, err := fmt.Fprintln(b.w, e.Command)
+	return err
+}
+
+func (b bashWriter) Flush() error { return b.w.Flush() }
+
+// zshWriter writes zsh's EXTENDED_HISTORY format,
+// ": <start>:<elapsed seconds>;<command>", with embedded newlines escaped by
+// a backslash as zsh does.
+type zshWriter struct{ w *bufio.Writer }
+
+func (z zshWriter) Write(e Entry) error {
+	elapsed := int64(0)
+	if e.Duration > 0 {
+		elapsed = int64(e.Duration / time.Second)
+	}
+	command := strings.ReplaceAll(e.Command, "\n", "\\\n")
+	_, err := fmt.Fprintf(z.w, ": %d:%d;%s\n", e.Time.Unix(), elapsed, command)
+	return err
+}
+
+func (z zshWriter) Flush() error { return z.w.Flush() }
+
+// fishWriter writes fish_history, a YAML-like list of "- cmd:" / "when:"
+// records with backslashes and newlines escaped.
+type fishWriter struct{ w *bufio.Writer }
+
+var fishEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func (f fishWriter) Write(e Entry) error {
+	_, err := fmt.Fprintf(f.w, "- cmd: %s\n  when: %d\n", fishEscaper.Replace(e.Command), e.Time.Unix())
+	return err
+}
+
+func (f fishWriter) Flush() error { return f.w.Flush() }
+
+// ImportFormats lists the formats Parse accepts. The export names
+// bash_history and zsh_extended work too.
+var ImportFormats = []string{"bash", "zsh", "fish", "powershell"}
+
+// Parse reads a history file in the given format, oldest entry first.
+func Parse(format string, r io.Reader) ([]Entry, error) {
+	switch format {
+	case "bash", "bash_history":
+		return parseBash(r)
+	case "zsh", "zsh_extended":
+		return parseZsh(r)
+	case "fish":
+		return parseFish(r)
+	case "powershell", "pwsh":
+		return parsePowerShell(r)
+	}
+	return nil, fmt.Errorf("unknown history format %q", format)
+}
+
+func newScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	// Pasted scripts make for very long history lines
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return scanner
+}
+
+// parseBash reads ~/.bash_history. With HISTTIMEFORMAT set bash precedes
+// each command with a "#<epoch>" line, and every line up to the next
+// timestamp belongs to the same (multi-line) command; without timestamps
+// each line is a command.
+func parseBash(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	var current *Entry
+	scanner := newScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if epoch, ok := bashTimestamp(line); ok {
+			entries = append(entries, Entry{Time: time.Unix(epoch, 0), Duration: -1})
+			current = &entries[len(entries)-1]
+			continue
+		}
+
+		switch {
+		case current == nil:
+			entries = append(entries, Entry{Command: line, Duration: -1})
+		case current.Command == "":
+			current.Command = line
+		default:
+			current.Command += "\n" + line
+		}
+	}
+
+	return dropEmpty(entries), scanner.Err()
+}
+
+func bashTimestamp(line string) (int64, bool) {
+	digits, ok := strings.CutPrefix(line, "#")
+	if !ok || digits == "" {
+		return 0, false
+	}
+	epoch, err := strconv.ParseInt(digits, 10, 64)
+	return epoch, err == nil
+}
+
+// parseZsh reads ~/.zsh_history in EXTENDED_HISTORY format
+// (": <start>:<elapsed>;<command>") or plain format, joining lines that end
+// in a backslash-escaped newline.
+func parseZsh(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	var pending strings.Builder
+	continued := false
+
+	scanner := newScanner(r)
+	for scanner.Scan() {
+		line := unmetafy(scanner.Text())
+
+		if continued {
+			pending.WriteString("\n")
+		}
+		if strings.HasSuffix(line, `\`) {
+			pending.WriteString(strings.TrimSuffix(line, `\`))
+			continued = true
+			continue
+		}
+		pending.WriteString(line)
+		continued = false
+
+		entries = append(entries, parseZshLine(pending.String()))
+		pending.Reset()
+	}
+	if pending.Len() > 0 {
+		entries = append(entries, parseZshLine(pending.String()))
+	}
+
+	return dropEmpty(entries), scanner.Err()
+}
+
+func parseZshLine(line string) Entry {
+	entry := Entry{Command: line, Duration: -1}
+
+	rest, ok := strings.CutPrefix(line, ": ")
+	if !ok {
+		return entry
+	}
+	header, command, ok := strings.Cut(rest, ";")
+	if !ok {
+		return entry
+	}
+	start, elapsed, ok := strings.Cut(header, ":")
+	if !ok {
+		return entry
+	}
+	startEpoch, err1 := strconv.ParseInt(start, 10, 64)
+	elapsedSeconds, err2 := strconv.ParseInt(elapsed, 10, 64)
+	if err1 != nil || err2 != nil {
+		return entry
+	}
+
+	return Entry{
+		Command:  command,
+		Time:     time.Unix(startEpoch, 0),
+		Duration: time.Duration(elapsedSeconds) * time.Second,
+	}
+}
+
+// unmetafy undoes zsh's encoding of special bytes in the history file,
+// where 0x83 marks the next byte as XORed with 32.
+func unmetafy(s string) string {
+	if !strings.Contains(s, "\x83") {
+		return s
+	}
+
+	b := []byte(s)
+	out := b[:0]
+	for i := 0; i < len(b); i++ {
+		if b[i] == 0x83 && i+1 < len(b) {
+			i++
+			out = append(out, b[i]^32)
+			continue
+		}
+		out = append(out, b[i])
+	}
+	return string(out)
+}
+
+// parseFish reads fish_history, a YAML subset of "- cmd:" records with a
+// "when:" epoch and an optional "paths:" list, which is ignored.
+func parseFish(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := newScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if command, ok := strings.CutPrefix(line, "- cmd: "); ok {
+			entries = append(entries, Entry{Command: fishUnescape(command), Duration: -1})
+			continue
+		}
+		if when, ok := strings.CutPrefix(strings.TrimSpace(line), "when: "); ok && len(entries) > 0 {
+			if epoch, err := strconv.ParseInt(when, 10, 64); err == nil {
+				entries[len(entries)-1].Time = time.Unix(epoch, 0)
+			}
+		}
+	}
+
+	return dropEmpty(entries), scanner.Err()
+}
+
+func fishUnescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			switch s[i+1] {
+			case '\\':
+				b.WriteByte('\\')
+				i++
+				continue
+			case 'n':
+				b.WriteByte('\n')
+				i++
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// parsePowerShell reads PSReadLine's ConsoleHost_history.txt: one command
+// per line, with multi-line commands continued by a trailing backtick. It
+// has no timestamps.
+func parsePowerShell(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	var pending strings.Builder
+
+	scanner := newScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+
+		if strings.HasSuffix(line, "`") {
+			pending.WriteString(strings.TrimSuffix(line, "`"))
+			pending.WriteString("\n")
+			continue
+		}
+		pending.WriteString(line)
+		entries = append(entries, Entry{Command: pending.String(), Duration: -1})
+		pending.Reset()
+	}
+	if pending.Len() > 0 {
+		entries = append(entries, Entry{Command: strings.TrimSuffix(pending.String(), "\n"), Duration: -1})
+	}
+
+	return dropEmpty(entries), scanner.Err()
+}
+
+func dropEmpty(entries []Entry) []Entry {
+	kept := entries[:0]
+	for _, e := range entries {
+		if strings.TrimSpace(e.Command) != "" {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,213 @@
+package histfile
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriter(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	entries := []Entry{
+		{Command: "ls -la", Time: at, Duration: 1500 * time.Millisecond},
+		{Command: "for i in 1 2\ndo echo $i\ndone", Time: at.Add(time.Minute), Duration: -1},
+		{Command: `echo a\b`, Time: at.Add(2 * time.Minute), Duration: 0},
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"bash_history", "#1700000000\nls -la\n" +
+			"#1700000060\nfor i in 1 2\ndo echo $i\ndone\n" +
+			"#1700000120\necho a\\b\n"},
+		{"zsh_extended", ": 1700000000:1;ls -la\n" +
+			": 1700000060:0;for i in 1 2\\\ndo echo $i\\\ndone\n" +
+			": 1700000120:0;echo a\\b\n"},
+		{"fish", "- cmd: ls -la\n  when: 1700000000\n" +
+			"- cmd: for i in 1 2\\ndo echo $i\\ndone\n  when: 1700000060\n" +
+			"- cmd: echo a\\\\b\n  when: 1700000120\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var b strings.Builder
+			w, err := NewWriter(tt.format, &b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range entries {
+				if err := w.Write(e); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("got\n%q\nwant\n%q", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestBashWriterWithoutTime(t *testing.T) {
+	var b strings.Builder
+	w, err := NewWriter("bash_history", &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(Entry{Command: "make", Duration: -1})
+	w.Flush()
+
+	if b.String() != "make\n" {
+		t.Errorf("got %q, want a bare command line", b.String())
+	}
+}
+
+func TestNewWriterUnknownFormat(t *testing.T) {
+	if _, err := NewWriter("csh", &strings.Builder{}); err == nil {
+		t.Error("NewWriter accepted an unknown format")
+	}
+}
+
+func TestParse(t *testing.T) {
+	unix := func(sec int64) time.Time { return time.Unix(sec, 0) }
+
+	tests := []struct {
+		name   string
+		format string
+		input  string
+		want   []Entry
+	}{
+		{
+			name:   "bash without timestamps",
+			format: "bash",
+			input:  "ls\n\ngit status\n",
+			want: []Entry{
+				{Command: "ls", Duration: -1},
+				{Command: "git status", Duration: -1},
+			},
+		},
+		{
+			name:   "bash with timestamps and a multi-line command",
+			format: "bash",
+			input:  "#1700000000\nls\n#1700000060\nfor i in 1 2\ndo echo $i\ndone\n",
+			want: []Entry{
+				{Command: "ls", Time: unix(1700000000), Duration: -1},
+				{Command: "for i in 1 2\ndo echo $i\ndone", Time: unix(1700000060), Duration: -1},
+			},
+		},
+		{
+			name:   "bash comment that isn't a timestamp",
+			format: "bash_history",
+			input:  "#not a time\nls\n",
+			want: []Entry{
+				{Command: "#not a time", Duration: -1},
+				{Command: "ls", Duration: -1},
+			},
+		},
+		{
+			name:   "zsh extended",
+			format: "zsh",
+			input:  ": 1700000000:3;make\n: 1700000060:0;echo one\\\necho two\n",
+			want: []Entry{
+				{Command: "make", Time: unix(1700000000), Duration: 3 * time.Second},
+				{Command: "echo one\necho two", Time: unix(1700000060), Duration: 0},
+			},
+		},
+		{
+			name:   "zsh plain",
+			format: "zsh_extended",
+			input:  "ls\n: not extended\n",
+			want: []Entry{
+				{Command: "ls", Duration: -1},
+				{Command: ": not extended", Duration: -1},
+			},
+		},
+		{
+			name:   "zsh metafied bytes",
+			format: "zsh",
+			input:  "echo \x83\xa2\n",
+			want:   []Entry{{Command: "echo \x82", Duration: -1}},
+		},
+		{
+			name:   "fish",
+			format: "fish",
+			input:  "- cmd: ls\n  when: 1700000000\n- cmd: echo a\\\\b\\nc\n  when: 1700000060\n  paths:\n    - a\\b\n",
+			want: []Entry{
+				{Command: "ls", Time: unix(1700000000), Duration: -1},
+				{Command: "echo a\\b\nc", Time: unix(1700000060), Duration: -1},
+			},
+		},
+		{
+			name:   "powershell with continuation",
+			format: "powershell",
+			input:  "Get-ChildItem\r\nGet-Process |`\r\n  Sort-Object CPU\r\n",
+			want: []Entry{
+				{Command: "Get-ChildItem", Duration: -1},
+				{Command: "Get-Process |\n  Sort-Object CPU", Duration: -1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.format, strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d entries %q, want %d", len(got), got, len(tt.want))
+			}
+			for i := range got {
+				if got[i].Command != tt.want[i].Command || !got[i].Time.Equal(tt.want[i].Time) ||
+					got[i].Duration != tt.want[i].Duration {
+					t.Errorf("entry %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseUnknownFormat(t *testing.T) {
+	if _, err := Parse("csh", strings.NewReader("ls\n")); err == nil {
+		t.Error("Parse accepted an unknown format")
+	}
+}
+
+// Exported history has to import back unchanged.
+func TestRoundTrip(t *testing.T) {
+	entries := []Entry{
+		{Command: "ls -la", Time: time.Unix(1700000000, 0), Duration: 2 * time.Second},
+		{Command: "echo one\necho two", Time: time.Unix(1700000060, 0), Duration: 0},
+		{Command: `printf 'a\nb'`, Time: time.Unix(1700000120, 0), Duration: 0},
+	}
+
+	for _, format := range []string{"zsh_extended", "fish"} {
+		t.Run(format, func(t *testing.T) {
+			var b strings.Builder
+			w, err := NewWriter(format, &b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range entries {
+				w.Write(e)
+			}
+			w.Flush()
+
+			got, err := Parse(format, strings.NewReader(b.String()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(entries) {
+				t.Fatalf("got %d entries back, want %d", len(got), len(entries))
+			}
+			for i := range got {
+				if got[i].Command != entries[i].Command || !got[i].Time.Equal(entries[i].Time) {
+					t.Errorf("entry %d = %+v, want %+v", i, got[i], entries[i])
+				}
+			}
+		})
+	}
+}
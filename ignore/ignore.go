@@ -0,0 +1,126 @@
+// Package ignore decides which commands are never written to the history.
+package ignore
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Rules holds parsed ignore patterns.
+//
+// Each pattern is one line:
+//
+//	ls                  glob on the whole command line (* and ? wildcards)
+//	git status*         ...so this matches "git status -s" too
+//	re:^(cd|ls)\b       regular expression searched in the command line
+//	dir:~/secret/**     glob on the working directory; /** also matches the
+//	                    directory itself, * stays within one path segment
+type Rules struct {
+	commands []rule
+	dirs     []rule
+	// IgnoreSpace skips commands typed with a leading space, like bash's
+	// HISTCONTROL=ignorespace.
+	IgnoreSpace bool
+}
+
+type rule struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+// Parse compiles patterns, expanding a leading ~ in directory patterns to
+// home.
+func Parse(patterns []string, home string) (*Rules, error) {
+	r := &Rules{IgnoreSpace: true}
+
+	for _, p := range patterns {
+		switch {
+		case strings.HasPrefix(p, "re:"):
+			re, err := regexp.Compile(strings.TrimPrefix(p, "re:"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid ignore pattern %q: %w", p, err)
+			}
+			r.commands = append(r.commands, rule{p, re})
+		case strings.HasPrefix(p, "dir:"):
+			dir := strings.TrimPrefix(p, "dir:")
+			if dir == "~" || strings.HasPrefix(dir, "~/") {
+				dir = filepath.ToSlash(home) + dir[1:]
+			}
+			r.dirs = append(r.dirs, rule{p, dirGlob(dir)})
+		default:
+			r.commands = append(r.commands, rule{p, commandGlob(p)})
+		}
+	}
+
+	return r, nil
+}
+
+// Match reports whether a command run in dir should not be logged, and which
+// rule says so.
+func (r *Rules) Match(command, dir string) (string, bool) {
+	if r.IgnoreSpace && command != "" && unicode.IsSpace(rune(command[0])) {
+		return "leading space", true
+	}
+
+	trimmed := strings.TrimSpace(command)
+	for _, rule := range r.commands {
+		if rule.re.MatchString(trimmed) {
+			return "pattern " + rule.pattern, true
+		}
+	}
+
+	dir = filepath.ToSlash(dir)
+	for _, rule := range r.dirs {
+		if rule.re.MatchString(dir) {
+			return "pattern " + rule.pattern, true
+		}
+	}
+
+	return "", false
+}
+
+func commandGlob(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, c := range pattern {
+		switch c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+func dirGlob(pattern string) *regexp.Regexp {
+	pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "/**"):
+			// "/**" matches the directory itself and everything below it
+			b.WriteString("(/.*)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			b.WriteString("[^/]*")
+		case pattern[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	b.WriteString("/?$")
+	return regexp.MustCompile(b.String())
+}
@@ -0,0 +1,69 @@
+package ignore
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	patterns := []string{
+		"ls",
+		"git status*",
+		"history ?",
+		`re:^(cd|pwd)\b`,
+		"dir:~/secret/**",
+		"dir:/tmp/*/scratch",
+	}
+	rules, err := Parse(patterns, "/home/me")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		command    string
+		dir        string
+		wantReason string
+	}{
+		{"exact glob", "ls", "/work", "pattern ls"},
+		{"glob is anchored", "ls -la", "/work", ""},
+		{"trailing star", "git status -s", "/work", "pattern git status*"},
+		{"star matches nothing", "git status", "/work", "pattern git status*"},
+		{"question mark", "history 5", "/work", "pattern history ?"},
+		{"question mark is one character", "history 10", "/work", ""},
+		{"regexp", "cd ..", "/work", `pattern re:^(cd|pwd)\b`},
+		{"regexp word boundary", "cdk deploy", "/work", ""},
+		{"surrounding space is trimmed", "ls\n", "/work", "pattern ls"},
+		{"leading space", " make deploy", "/work", "leading space"},
+		{"home directory itself", "make", "/home/me/secret", "pattern dir:~/secret/**"},
+		{"below home directory", "make", "/home/me/secret/a/b", "pattern dir:~/secret/**"},
+		{"sibling of home directory", "make", "/home/me/secrets", ""},
+		{"single segment star", "make", "/tmp/x/scratch", "pattern dir:/tmp/*/scratch"},
+		{"star stays in one segment", "make", "/tmp/x/y/scratch", ""},
+		{"not ignored", "make test", "/work", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, ignored := rules.Match(tt.command, tt.dir)
+			if reason != tt.wantReason || ignored != (tt.wantReason != "") {
+				t.Errorf("Match(%q, %q) = %q, %v; want %q", tt.command, tt.dir, reason, ignored, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestMatchIgnoreSpaceOff(t *testing.T) {
+	rules, err := Parse(nil, "/home/me")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules.IgnoreSpace = false
+
+	if reason, ignored := rules.Match(" make deploy", "/work"); ignored {
+		t.Errorf("Match ignored a leading-space command with IgnoreSpace off: %q", reason)
+	}
+}
+
+func TestParseInvalidRegexp(t *testing.T) {
+	if _, err := Parse([]string{"re:("}, "/home/me"); err == nil {
+		t.Error("Parse accepted an invalid regular expression")
+	}
+}
@@ -0,0 +1,194 @@
+// Package redact scrubs secrets out of command lines before they are stored.
+package redact
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// Placeholder replaces a secret of the given kind.
+func Placeholder(kind string) string {
+	return "<redacted:" + kind + ">"
+}
+
+// A Rule finds one kind of secret. If Pattern has a group named "secret" only
+// that group is replaced, so the surrounding context ("--password=",
+// "Authorization: Bearer ") stays readable; otherwise the whole match is.
+type Rule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// BuiltinRules are applied by every Redactor, in order.
+var BuiltinRules = []Rule{
+	{
+		Name:    "auth-header",
+		Pattern: regexp.MustCompile(`(?i)\b(?:authorization|proxy-authorization)\s*:\s*(?:(?:bearer|basic|token|digest)\s+)?(?P<secret>[^\s"']+)`),
+	},
+	{
+		Name:    "header-token",
+		Pattern: regexp.MustCompile(`(?i)\bx-(?:api-key|auth-token|access-token)\s*:\s*(?P<secret>[^\s"']+)`),
+	},
+	{
+		Name:    "url-credentials",
+		Pattern: regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.-]*://[^\s:/@]+:(?P<secret>[^\s@/]+)@`),
+	},
+	{
+		Name:    "password-flag",
+		Pattern: regexp.MustCompile(`(?i)(?:^|\s)--?(?:password|passwd|pass|token|secret|api-key|apikey|access-key|secret-key|client-secret|auth-token)(?:=|\s+)(?P<secret>"[^"]*"|'[^']*'|[^\s-][^\s]*)`),
+	},
+	{
+		// Tools where -p is the password rather than a port or a path
+		Name:    "login-password",
+		Pattern: regexp.MustCompile(`\b(?:(?:docker|podman)\s+login|sshpass)\b.*?\s-p(?:=|\s*)(?P<secret>"[^"]*"|'[^']*'|[^\s-][^\s]*)`),
+	},
+	{
+		// Only assignments at the start of a word, so flags like
+		// --author=bob and prose like auth=true are left alone
+		Name:    "secret-variable",
+		Pattern: regexp.MustCompile(`(?im)(?:^|\s)[A-Z0-9_]*(?:SECRET|TOKEN|PASSWORD|PASSWD|PASSPHRASE|API_?KEY|ACCESS_?KEY|PRIVATE_?KEY|CREDENTIALS?|AUTH_|_AUTH)[A-Z0-9_]*=(?P<secret>"[^"]*"|'[^']*'|[^\s]+)`),
+	},
+	{
+		// mysql -pSECRET and friends take the password glued to -p
+		Name:    "mysql-password",
+		Pattern: regexp.MustCompile(`\b(?:mysql|mysqldump|mysqladmin|mariadb|mariadb-dump)\b.*?\s-p(?P<secret>[^\s]+)`),
+	},
+	{
+		Name:    "private-key",
+		Pattern: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+	},
+	{
+		Name:    "token",
+		Pattern: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,}|glpat-[A-Za-z0-9_-]{20,}|xox[abposr]-[A-Za-z0-9-]{10,}|sk-[A-Za-z0-9_-]{20,}|AKIA[0-9A-Z]{16}|AIza[0-9A-Za-z_-]{35}|npm_[A-Za-z0-9]{36})\b`),
+	},
+	{
+		Name:    "jwt",
+		Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{5,}`),
+	},
+}
+
+// Redactor applies the built-in rules, any custom rules and high-entropy
+// string detection to command lines.
+type Redactor struct {
+	rules []Rule
+	// HighEntropy turns on detection of long random-looking strings that no
+	// rule matched.
+	HighEntropy bool
+}
+
+// New returns a Redactor using the built-in rules plus one custom rule per
+// regular expression in custom.
+func New(custom []string) (*Redactor, error) {
+	r := &Redactor{
+		rules:       append([]Rule{}, BuiltinRules...),
+		HighEntropy: true,
+	}
+
+	for i, expr := range custom {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", expr, err)
+		}
+		r.rules = append(r.rules, Rule{Name: fmt.Sprintf("custom-%d", i+1), Pattern: re})
+	}
+
+	return r, nil
+}
+
+// Redact returns command with every detected secret replaced by a
+// placeholder.
+func (r *Redactor) Redact(command string) string {
+	for _, rule := range r.rules {
+		command = applyRule(rule, command)
+	}
+	if r.HighEntropy {
+		command = redactHighEntropy(command)
+	}
+	return command
+}
+
+func applyRule(rule Rule, s string) string {
+	group := rule.Pattern.SubexpIndex("secret")
+	matches := rule.Pattern.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return s
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if group > 0 {
+			start, end = m[2*group], m[2*group+1]
+			if start < 0 {
+				continue
+			}
+		}
+		// Leave earlier placeholders alone
+		if strings.HasPrefix(s[start:end], "<redacted:") {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(Placeholder(rule.Name))
+		last = end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+var (
+	entropyCandidate = regexp.MustCompile(`[A-Za-z0-9+/_\-]{24,}={0,2}`)
+	// identifier matches names like TestFooBar_WithLongName123, whose
+	// digits only come at the end of a word
+	identifier = regexp.MustCompile(`^[A-Za-z]+[0-9]*(?:[_-][A-Za-z]+[0-9]*)*$`)
+)
+
+// redactHighEntropy replaces long tokens that look random: they contain
+// digits as well as letters, and their character entropy is close to the
+// maximum for their length. Hex strings such as git hashes and UUIDs stay
+// below the threshold, and identifiers are skipped.
+func redactHighEntropy(s string) string {
+	return entropyCandidate.ReplaceAllStringFunc(s, func(token string) string {
+		if strings.Count(token, "/") > 1 || !hasLettersAndDigits(token) || identifier.MatchString(token) {
+			return token
+		}
+
+		threshold := 0.85 * math.Log2(math.Min(float64(len(token)), 64))
+		if shannonEntropy(token) < threshold {
+			return token
+		}
+		return Placeholder("high-entropy")
+	})
+}
+
+// hasLettersAndDigits reports whether s contains at least one digit and one
+// ASCII letter of either case.
+func hasLettersAndDigits(s string) bool {
+	var letter, digit bool
+	for _, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+			letter = true
+		case c >= '0' && c <= '9':
+			digit = true
+		}
+	}
+	return letter && digit
+}
+
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, c := range s {
+		counts[c]++
+	}
+
+	entropy := 0.0
+	n := float64(len(s))
+	for _, count := range counts {
+		p := float64(count) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}